@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type Advisory struct {
+	ID                 int    `json:"id"`
+	Title              string `json:"title"`
+	Severity           string `json:"severity"`
+	VulnerableVersions string `json:"vulnerable_versions"`
+	URL                string `json:"url"`
+}
+
+var auditSeverityRank = map[string]int{
+	"info":     0,
+	"low":      1,
+	"moderate": 2,
+	"high":     3,
+	"critical": 4,
+}
+
+func (pm *PackageManager) auditPackages(lockFile *LockFile) (map[string][]Advisory, error) {
+	request := make(map[string][]string)
+
+	lockFile.mu.RLock()
+	for _, pkg := range lockFile.Packages {
+		request[pkg.Name] = append(request[pkg.Name], pkg.Version)
+	}
+	lockFile.mu.RUnlock()
+
+	if len(request) == 0 {
+		return map[string][]Advisory{}, nil
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit request: %v", err)
+	}
+
+	ctx, cancel := requestContext(auditTimeout)
+	defer cancel()
+
+	url := pm.auditEndpoint()
+	req, err := pm.newRegistryRequest(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to contact audit endpoint: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := pm.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to contact audit endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("audit endpoint error: status %d from %s", resp.StatusCode, requestHost(url))
+	}
+
+	var advisories map[string][]Advisory
+	if err := json.NewDecoder(resp.Body).Decode(&advisories); err != nil {
+		return nil, fmt.Errorf("failed to parse audit response: %v", err)
+	}
+
+	return advisories, nil
+}
+
+func (pm *PackageManager) auditEndpoint() string {
+	registry := strings.TrimSuffix(pm.auditRegistry, "/")
+	if registry == "" {
+		registry = pm.registryURL
+	}
+	if strings.Contains(registry, "/-/npm/v1/security/") {
+		return registry
+	}
+	return registry + "/-/npm/v1/security/advisories/bulk"
+}
+
+func filterAdvisories(advisories map[string][]Advisory, level string) map[string][]Advisory {
+	threshold := auditSeverityRank[level]
+	filtered := make(map[string][]Advisory)
+
+	for name, list := range advisories {
+		for _, advisory := range list {
+			if auditSeverityRank[advisory.Severity] >= threshold {
+				filtered[name] = append(filtered[name], advisory)
+			}
+		}
+	}
+
+	return filtered
+}
+
+func printAuditSummary(advisories map[string][]Advisory) {
+	if len(advisories) == 0 {
+		fmt.Printf(" %s No known vulnerabilities found\n", color.HiGreenString("✓"))
+		return
+	}
+
+	names := make([]string, 0, len(advisories))
+	counts := make(map[string]int)
+	total := 0
+	for name, list := range advisories {
+		names = append(names, name)
+		for _, advisory := range list {
+			counts[advisory.Severity]++
+			total++
+		}
+	}
+	sort.Strings(names)
+
+	fmt.Printf("\n %s Found %d known vulnerabilities\n", color.RedString("✗"), total)
+	for _, name := range names {
+		for _, advisory := range advisories[name] {
+			fmt.Printf("   %s %s %s %s\n",
+				severityColor(advisory.Severity),
+				color.CyanString(name),
+				advisory.Title,
+				color.HiBlackString(advisory.VulnerableVersions))
+			if advisory.URL != "" {
+				fmt.Printf("     %s\n", color.HiBlackString(advisory.URL))
+			}
+		}
+	}
+
+	fmt.Printf("\n %s critical: %d, high: %d, moderate: %d, low: %d, info: %d\n",
+		color.MagentaString("→"),
+		counts["critical"], counts["high"], counts["moderate"], counts["low"], counts["info"])
+}
+
+func severityColor(severity string) string {
+	switch severity {
+	case "critical", "high":
+		return color.RedString("%-8s", severity)
+	case "moderate":
+		return color.YellowString("%-8s", severity)
+	default:
+		return color.HiBlackString("%-8s", severity)
+	}
+}
+
+func validAuditLevel(level string) bool {
+	_, ok := auditSeverityRank[level]
+	return ok
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type AuditFix struct {
+	Name        string
+	FromVersion string
+	ToVersion   string
+	IsDev       bool
+	Reason      string
+}
+
+func planAuditFixes(pm *PackageManager, lockFile *LockFile, advisories map[string][]Advisory, allowMajor bool) ([]AuditFix, []AuditFix, error) {
+	pkg, err := loadPackageJSON(projectManifestPath())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(advisories))
+	for name := range advisories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fixes, unfixed []AuditFix
+	for _, name := range names {
+		fix := AuditFix{Name: name, FromVersion: lockFile.getPackageVersion(name)}
+
+		allowedRange, direct := pkg.Dependencies[name]
+		if !direct {
+			allowedRange, direct = pkg.DevDependencies[name]
+			fix.IsDev = direct
+		}
+		if !direct {
+			fix.Reason = "not a direct dependency; upgrade the package that depends on it"
+			unfixed = append(unfixed, fix)
+			continue
+		}
+
+		registryResp, err := pm.fetchRegistryResponse(name)
+		if err != nil {
+			fix.Reason = fmt.Sprintf("failed to fetch versions: %v", err)
+			unfixed = append(unfixed, fix)
+			continue
+		}
+
+		fix.ToVersion = nearestSafeVersion(fix.FromVersion, registryResp, advisories[name], allowedRange, allowMajor)
+		if fix.ToVersion != "" {
+			fixes = append(fixes, fix)
+			continue
+		}
+
+		if !allowMajor && nearestSafeVersion(fix.FromVersion, registryResp, advisories[name], allowedRange, true) != "" {
+			fix.Reason = fmt.Sprintf("no safe version within %s (use --allow-major to cross major versions)", allowedRange)
+		} else {
+			fix.Reason = "no safe version has been published"
+		}
+		unfixed = append(unfixed, fix)
+	}
+
+	return fixes, unfixed, nil
+}
+
+func nearestSafeVersion(current string, registryResp *RegistryResponse, advisories []Advisory, allowedRange string, allowMajor bool) string {
+	var candidates []string
+	for version := range registryResp.Versions {
+		if strings.Contains(version, "-") || compareVersions(version, current) <= 0 {
+			continue
+		}
+		if !allowMajor && !satisfiesRange(version, allowedRange) {
+			continue
+		}
+
+		vulnerable := false
+		for _, advisory := range advisories {
+			if advisory.VulnerableVersions != "" && satisfiesRange(version, advisory.VulnerableVersions) {
+				vulnerable = true
+			}
+		}
+		if !vulnerable {
+			candidates = append(candidates, version)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return compareVersions(candidates[i], candidates[j]) < 0
+	})
+	return candidates[0]
+}
+
+func printAuditFixes(fixes, unfixed []AuditFix) {
+	if len(fixes) > 0 {
+		fmt.Printf("\n %s Fixed %d vulnerable package(s):\n", color.HiGreenString("✓"), len(fixes))
+		for _, fix := range fixes {
+			fmt.Printf("   %s %s %s %s\n", color.CyanString(fix.Name), color.RedString(fix.FromVersion), color.BlueString("→"), color.GreenString(fix.ToVersion))
+		}
+	}
+
+	if len(unfixed) > 0 {
+		fmt.Printf("\n %s Could not fix %d package(s):\n", color.YellowString("⚠"), len(unfixed))
+		for _, fix := range unfixed {
+			fmt.Printf("   %s %s\n", color.CyanString("%s@%s", fix.Name, fix.FromVersion), color.HiBlackString(fix.Reason))
+		}
+	}
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
-
-	"github.com/fatih/color"
 )
 
 type BinaryManager struct {
@@ -18,11 +20,54 @@ type BinaryManager struct {
 
 func NewBinaryManager() *BinaryManager {
 	return &BinaryManager{
-		nodeModulesPath: "./node_modules",
-		binPath:         "./node_modules/.bin",
+		nodeModulesPath: nodeModulesDir(),
+		binPath:         filepath.Join(nodeModulesDir(), ".bin"),
 	}
 }
 
+type binField struct {
+	path    string
+	entries map[string]string
+}
+
+func (b *binField) UnmarshalJSON(data []byte) error {
+	var binPath string
+	if err := json.Unmarshal(data, &binPath); err == nil {
+		b.path = binPath
+		return nil
+	}
+
+	var entries map[string]interface{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+	b.entries = make(map[string]string, len(entries))
+	for name, value := range entries {
+		if binPath, ok := value.(string); ok && binPath != "" {
+			b.entries[name] = binPath
+		}
+	}
+	return nil
+}
+
+func (b binField) binaries(packageName string) map[string]string {
+	if b.path != "" {
+		return map[string]string{path.Base(packageName): b.path}
+	}
+	binaries := make(map[string]string, len(b.entries))
+	for name, binPath := range b.entries {
+		binaries[name] = binPath
+	}
+	return binaries
+}
+
+func manifestName(name, packageName string) string {
+	if name != "" {
+		return name
+	}
+	return packageName
+}
+
 func (bm *BinaryManager) setupPackageBinaries(packageName string) error {
 	packagePath := filepath.Join(bm.nodeModulesPath, packageName)
 	packageJSONPath := filepath.Join(packagePath, "package.json")
@@ -37,8 +82,8 @@ func (bm *BinaryManager) setupPackageBinaries(packageName string) error {
 	}
 
 	var pkg struct {
-		Name string          `json:"name"`
-		Bin  json.RawMessage `json:"bin"`
+		Name string   `json:"name"`
+		Bin  binField `json:"bin"`
 	}
 
 	if err := json.Unmarshal(data, &pkg); err != nil {
@@ -49,25 +94,18 @@ func (bm *BinaryManager) setupPackageBinaries(packageName string) error {
 		return fmt.Errorf("failed to create .bin directory: %v", err)
 	}
 
-	binaries := make(map[string]string)
-
-	if len(pkg.Bin) > 0 {
+	binaries := pkg.Bin.binaries(manifestName(pkg.Name, packageName))
 
-		var binMap map[string]string
-		if err := json.Unmarshal(pkg.Bin, &binMap); err == nil {
-			binaries = binMap
-		} else {
-
-			var binString string
-			if err := json.Unmarshal(pkg.Bin, &binString); err == nil {
-				binaries[packageName] = binString
-			}
-		}
+	binNames := make([]string, 0, len(binaries))
+	for binName := range binaries {
+		binNames = append(binNames, binName)
 	}
+	sort.Strings(binNames)
 
-	for binName, binPath := range binaries {
+	for _, binName := range binNames {
+		binPath := binaries[binName]
 		if err := bm.createBinaryLink(packageName, binName, binPath); err != nil {
-			fmt.Printf(" %s Failed to link binary %s: %v\n", color.YellowString("⚠"), binName, err)
+			reportWarning("Failed to link binary %s: %v", binName, err)
 		}
 	}
 
@@ -75,8 +113,12 @@ func (bm *BinaryManager) setupPackageBinaries(packageName string) error {
 }
 
 func (bm *BinaryManager) createBinaryLink(packageName, binName, binPath string) error {
-	sourcePath := filepath.Join(bm.nodeModulesPath, packageName, binPath)
-	targetPath := filepath.Join(bm.binPath, binName)
+	packagePath := filepath.Join(bm.nodeModulesPath, packageName)
+	sourcePath, err := binarySourcePath(packagePath, binPath)
+	if err != nil {
+		return err
+	}
+	targetPath := filepath.Join(bm.binPath, binLinkName(binName))
 
 	if !fileExists(sourcePath) {
 		return fmt.Errorf("binary source not found: %s", sourcePath)
@@ -87,17 +129,115 @@ func (bm *BinaryManager) createBinaryLink(packageName, binName, binPath string)
 	}
 
 	if runtime.GOOS == "windows" {
-		return bm.createWindowsBinary(sourcePath, targetPath)
+		err = bm.createWindowsBinary(sourcePath, targetPath)
 	} else {
-		return bm.createUnixBinary(sourcePath, targetPath)
+		err = bm.createUnixBinary(sourcePath, targetPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	return verifyBinaryShim(sourcePath, targetPath)
+}
+
+func binLinkName(binName string) string {
+	return path.Base(filepath.ToSlash(binName))
+}
+
+func binarySourcePath(packagePath, binPath string) (string, error) {
+	sourcePath := filepath.Join(packagePath, binPath)
+	rel, err := filepath.Rel(packagePath, sourcePath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("bin path %q points outside the package", binPath)
 	}
+	return sourcePath, nil
+}
+
+func relativeBinarySource(sourcePath, targetPath string) (string, error) {
+	return filepath.Rel(filepath.Dir(targetPath), sourcePath)
+}
+
+func verifyBinaryShim(sourcePath, targetPath string) error {
+	relativeSource, err := relativeBinarySource(sourcePath, targetPath)
+	if err != nil {
+		return err
+	}
+
+	resolved := filepath.Join(filepath.Dir(targetPath), relativeSource)
+	if filepath.Clean(resolved) != filepath.Clean(sourcePath) || !fileExists(resolved) {
+		return fmt.Errorf("shim %s does not resolve to %s", targetPath, sourcePath)
+	}
+	return nil
+}
+
+func shellQuotePath(p string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	return replacer.Replace(p)
+}
+
+func cmdQuotePath(p string) string {
+	return strings.ReplaceAll(p, "%", "%%")
+}
+
+func powershellQuotePath(p string) string {
+	replacer := strings.NewReplacer("`", "``", "$", "`$", `"`, "`\"")
+	return replacer.Replace(p)
+}
+
+var nativeBinaryMagics = [][]byte{
+	{0x7f, 'E', 'L', 'F'},
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+	{0xca, 0xfe, 0xba, 0xbe},
+}
+
+func isDirectlyExecutable(sourcePath string) bool {
+	file, err := os.Open(sourcePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, 4)
+	n, _ := io.ReadFull(file, header)
+	header = header[:n]
+
+	if bytes.HasPrefix(header, []byte("#!")) {
+		return true
+	}
+	for _, magic := range nativeBinaryMagics {
+		if bytes.Equal(header, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+func makeExecutable(sourcePath string) error {
+	info, err := os.Stat(sourcePath)
+	if err != nil || !info.Mode().IsRegular() {
+		return err
+	}
+	return os.Chmod(sourcePath, 0755)
 }
 
 func (bm *BinaryManager) createUnixBinary(sourcePath, targetPath string) error {
-	relativeSource, err := filepath.Rel(filepath.Dir(targetPath), sourcePath)
+	relativeSource, err := relativeBinarySource(sourcePath, targetPath)
 	if err != nil {
 		return err
 	}
+	relativeSource = shellQuotePath(filepath.ToSlash(relativeSource))
+
+	if err := makeExecutable(sourcePath); err != nil {
+		return fmt.Errorf("failed to make %s executable: %v", sourcePath, err)
+	}
+
+	command := fmt.Sprintf(`exec node "$basedir/%s" "$@"`, relativeSource)
+	if isDirectlyExecutable(sourcePath) {
+		command = fmt.Sprintf(`exec "$basedir/%s" "$@"`, relativeSource)
+	}
 
 	script := fmt.Sprintf(`#!/bin/sh
 basedir=$(dirname "$(echo "$0" | sed -e 's,\\,/,g')")
@@ -106,12 +246,8 @@ case "$(uname -s)" in
     *CYGWIN*|*MINGW*|*MSYS*) basedir=$(cygpath -w "$basedir");;
 esac
 
-if [ -x "$basedir/%s" ]; then
-  exec "$basedir/%s" "$@"
-else
-  exec node "$basedir/%s" "$@"
-fi
-`, relativeSource, relativeSource, relativeSource)
+%s
+`, command)
 
 	if err := os.WriteFile(targetPath, []byte(script), 0755); err != nil {
 		return err
@@ -121,12 +257,14 @@ fi
 }
 
 func (bm *BinaryManager) createWindowsBinary(sourcePath, targetPath string) error {
-	relativeSource, err := filepath.Rel(filepath.Dir(targetPath), sourcePath)
+	relativeSource, err := relativeBinarySource(sourcePath, targetPath)
 	if err != nil {
 		return err
 	}
 
 	relativeSource = strings.ReplaceAll(relativeSource, "/", "\\")
+	cmdSource := cmdQuotePath(relativeSource)
+	psSource := powershellQuotePath(filepath.ToSlash(relativeSource))
 
 	cmdScript := fmt.Sprintf(`@ECHO off
 GOTO start
@@ -145,7 +283,7 @@ IF EXIST "%%dp0\%s" (
 )
 
 endLocal & goto #_undefined_# 2>NUL || title %%COMSPEC%% & "%%_prog%%" %%*
-`, relativeSource, relativeSource, relativeSource)
+`, cmdSource, cmdSource, cmdSource)
 
 	cmdPath := targetPath + ".cmd"
 	if err := os.WriteFile(cmdPath, []byte(cmdScript), 0755); err != nil {
@@ -168,7 +306,7 @@ if (Test-Path "$basedir/%s$exe") {
   $ret=$LASTEXITCODE
 }
 exit $ret
-`, relativeSource, relativeSource, relativeSource)
+`, psSource, psSource, psSource)
 
 	ps1Path := targetPath + ".ps1"
 	if err := os.WriteFile(ps1Path, []byte(psScript), 0755); err != nil {
@@ -192,32 +330,18 @@ func (bm *BinaryManager) removePackageBinaries(packageName string) error {
 	}
 
 	var pkg struct {
-		Name string          `json:"name"`
-		Bin  json.RawMessage `json:"bin"`
+		Name string   `json:"name"`
+		Bin  binField `json:"bin"`
 	}
 
 	if err := json.Unmarshal(data, &pkg); err != nil {
 		return nil
 	}
 
-	binaries := make(map[string]string)
-
-	if len(pkg.Bin) > 0 {
-
-		var binMap map[string]string
-		if err := json.Unmarshal(pkg.Bin, &binMap); err == nil {
-			binaries = binMap
-		} else {
-
-			var binString string
-			if err := json.Unmarshal(pkg.Bin, &binString); err == nil {
-				binaries[packageName] = binString
-			}
-		}
-	}
+	binaries := pkg.Bin.binaries(manifestName(pkg.Name, packageName))
 
 	for binName := range binaries {
-		targetPath := filepath.Join(bm.binPath, binName)
+		targetPath := filepath.Join(bm.binPath, binLinkName(binName))
 		os.Remove(targetPath)
 		os.Remove(targetPath + ".cmd")
 		os.Remove(targetPath + ".ps1")
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBinFieldUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		packageName string
+		data        string
+		want        map[string]string
+	}{
+		{"string", "typescript", `"./bin/tsc"`, map[string]string{"typescript": "./bin/tsc"}},
+		{"scoped string", "@scope/cli", `"cli.js"`, map[string]string{"cli": "cli.js"}},
+		{"object", "typescript", `{"tsc": "./bin/tsc", "tsserver": "./bin/tsserver"}`, map[string]string{"tsc": "./bin/tsc", "tsserver": "./bin/tsserver"}},
+		{"object skips non-strings", "pkg", `{"good": "a.js", "empty": "", "bad": 1}`, map[string]string{"good": "a.js"}},
+		{"number", "pkg", `42`, map[string]string{}},
+		{"array", "pkg", `["a.js"]`, map[string]string{}},
+		{"null", "pkg", `null`, map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var manifest struct {
+				Bin binField `json:"bin"`
+			}
+			if err := json.Unmarshal([]byte(`{"bin": `+tt.data+`}`), &manifest); err != nil {
+				t.Fatalf("unmarshal failed: %v", err)
+			}
+			if got := manifest.Bin.binaries(tt.packageName); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("binaries(%q) = %v, want %v", tt.packageName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetupPackageBinariesLinksNestedScopedBin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("checks the sh shim")
+	}
+
+	nodeModules := filepath.Join(t.TempDir(), "node_modules")
+	packagePath := filepath.Join(nodeModules, "@scope", "tool")
+	if err := os.MkdirAll(filepath.Join(packagePath, "dist", "cli"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(packagePath, "package.json"), []byte(`{"name": "@scope/tool", "bin": "dist/cli/index.js"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(packagePath, "dist", "cli", "index.js"), []byte("#!/bin/sh\necho tool ran\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bm := &BinaryManager{nodeModulesPath: nodeModules, binPath: filepath.Join(nodeModules, ".bin")}
+	if err := bm.setupPackageBinaries("@scope/tool"); err != nil {
+		t.Fatalf("setupPackageBinaries: %v", err)
+	}
+
+	shim := filepath.Join(nodeModules, ".bin", "tool")
+	out, err := exec.Command(shim).CombinedOutput()
+	if err != nil {
+		t.Fatalf("running %s: %v\n%s", shim, err, out)
+	}
+	if strings.TrimSpace(string(out)) != "tool ran" {
+		t.Errorf("shim output = %q, want %q", out, "tool ran")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 )
 
@@ -46,42 +47,42 @@ func (c *Cache) getPackagePath(name, version string) string {
 	return filepath.Join(c.cacheDir, fmt.Sprintf("%s-%s-%s", name, version, hashStr))
 }
 
-func (c *Cache) hasPackage(name, version string) bool {
-	packagePath := c.getPackagePath(name, version)
-	_, err := os.Stat(packagePath)
-	return err == nil
+func (c *Cache) storeIntegrity(name, version, integrity string) error {
+	return os.WriteFile(c.getPackagePath(name, version)+".integrity", []byte(integrity), 0644)
 }
 
-func (c *Cache) storePackage(name, version string, tarballReader io.Reader) error {
-	packagePath := c.getPackagePath(name, version)
-
-	if err := os.MkdirAll(filepath.Dir(packagePath), 0755); err != nil {
-		return err
-	}
-
-	file, err := os.Create(packagePath + ".tgz")
+func (c *Cache) getIntegrity(name, version string) string {
+	data, err := os.ReadFile(c.getPackagePath(name, version) + ".integrity")
 	if err != nil {
-		return err
+		return ""
 	}
-	defer file.Close()
+	return strings.TrimSpace(string(data))
+}
 
-	_, err = io.Copy(file, tarballReader)
-	return err
+func (c *Cache) hasPackage(name, version string) bool {
+	return fileExists(c.tarballPath(name, version))
 }
 
-func (c *Cache) getPackage(name, version string) (io.ReadCloser, error) {
-	packagePath := c.getPackagePath(name, version) + ".tgz"
-	return os.Open(packagePath)
+func (c *Cache) createTarball(name, version string) (*os.File, error) {
+	dir := filepath.Dir(c.getPackagePath(name, version))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(dir, ".gpm-tarball-")
 }
 
-func (c *Cache) copyToNodeModules(name, version, destPath string) error {
-	packagePath := c.getPackagePath(name, version)
+func (c *Cache) storePackage(name, version, tarballPath string) error {
+	if err := os.Chmod(tarballPath, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tarballPath, c.tarballPath(name, version))
+}
 
+func (c *Cache) copyToNodeModules(name, version, destPath string, workers int) error {
 	if !c.hasPackage(name, version) {
 		return fmt.Errorf("package not in cache")
 	}
-
-	return copyDirectory(packagePath, destPath)
+	return c.extractTarball(name, version, destPath, workers)
 }
 
 func copyDirectory(src, dst string) error {
@@ -98,7 +99,7 @@ func copyDirectory(src, dst string) error {
 		destPath := filepath.Join(dst, relPath)
 
 		if info.IsDir() {
-			return os.MkdirAll(destPath, info.Mode())
+			return os.MkdirAll(destPath, 0755)
 		}
 
 		return copyFile(path, destPath)
@@ -116,14 +117,21 @@ func copyFile(src, dst string) error {
 		return err
 	}
 
-	destFile, err := os.Create(dst)
+	info, err := sourceFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	destFile, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
 	if err != nil {
 		return err
 	}
 	defer destFile.Close()
 
-	_, err = io.Copy(destFile, sourceFile)
-	return err
+	if _, err := io.Copy(destFile, sourceFile); err != nil {
+		return err
+	}
+	return destFile.Chmod(info.Mode().Perm())
 }
 
 func (c *Cache) getCacheSize() (int64, error) {
@@ -146,21 +154,79 @@ func (c *Cache) clear() error {
 	return os.RemoveAll(c.cacheDir)
 }
 
-func (c *Cache) getPackageCount() (int, error) {
-	count := 0
-	err := filepath.Walk(c.cacheDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+func (c *Cache) cachedVersions(name string) ([]string, error) {
+	dir := filepath.Dir(c.getPackagePath(name, ""))
+	base := filepath.Base(filepath.FromSlash(name)) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var versions []string
+	for _, entry := range entries {
+		entryName := entry.Name()
+		for _, suffix := range []string{".tgz", ".integrity"} {
+			entryName = strings.TrimSuffix(entryName, suffix)
 		}
-		if info.IsDir() && path != c.cacheDir {
-			relPath, _ := filepath.Rel(c.cacheDir, path)
-			if !strings.Contains(relPath, string(os.PathSeparator)) {
-				count++
-			}
+		if !strings.HasPrefix(entryName, base) {
+			continue
 		}
-		return nil
+
+		hashIndex := strings.LastIndex(entryName, "-")
+		if hashIndex < len(base) {
+			continue
+		}
+		version := entryName[len(base):hashIndex]
+		if seen[version] || filepath.Base(c.getPackagePath(name, version)) != entryName {
+			continue
+		}
+		seen[version] = true
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
 	})
-	return count, err
+	return versions, nil
+}
+
+func (c *Cache) removePackage(name, version string) (int64, error) {
+	var freed int64
+	packagePath := c.getPackagePath(name, version)
+
+	for _, path := range []string{packagePath + tarballExt, packagePath + ".integrity"} {
+		err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				freed += info.Size()
+			}
+			return nil
+		})
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return freed, err
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			return freed, err
+		}
+	}
+
+	return freed, nil
+}
+
+func (c *Cache) getPackageCount() (int, error) {
+	packages, err := c.listPackages()
+	return len(packages), err
 }
 
 type CachedPackage struct {
@@ -171,31 +237,60 @@ type CachedPackage struct {
 
 func (c *Cache) listPackages() ([]CachedPackage, error) {
 	var packages []CachedPackage
+	seen := make(map[string]bool)
 
-	err := filepath.Walk(c.cacheDir, func(path string, info os.FileInfo, err error) error {
+	var scan func(dir, scope string) error
+	scan = func(dir, scope string) error {
+		entries, err := os.ReadDir(dir)
 		if err != nil {
 			return err
 		}
 
-		if info.IsDir() && path != c.cacheDir {
-			relPath, _ := filepath.Rel(c.cacheDir, path)
-			if !strings.Contains(relPath, string(os.PathSeparator)) {
-				name := filepath.Base(path)
-				parts := strings.Split(name, "-")
-				if len(parts) >= 3 {
-					version := parts[len(parts)-2]
-					packageName := strings.Join(parts[:len(parts)-2], "-")
-
-					packages = append(packages, CachedPackage{
-						Name:    packageName,
-						Version: version,
-						Path:    path,
-					})
+		for _, entry := range entries {
+			entryName := entry.Name()
+			if scope == "" && entry.IsDir() && strings.HasPrefix(entryName, "@") {
+				if err := scan(filepath.Join(dir, entryName), entryName); err != nil {
+					return err
 				}
+				continue
 			}
+			if entry.IsDir() || !strings.HasSuffix(entryName, tarballExt) {
+				continue
+			}
+
+			name, version, ok := c.parseEntryName(scope, strings.TrimSuffix(entryName, tarballExt))
+			if !ok || seen[name+"@"+version] {
+				continue
+			}
+			seen[name+"@"+version] = true
+			packages = append(packages, CachedPackage{
+				Name:    name,
+				Version: version,
+				Path:    filepath.Join(dir, entryName),
+			})
 		}
 		return nil
-	})
+	}
+
+	if err := scan(c.cacheDir, ""); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return packages, nil
+}
 
-	return packages, err
+func (c *Cache) parseEntryName(scope, entryName string) (string, string, bool) {
+	hashIndex := strings.LastIndex(entryName, "-")
+	for i := 1; i < hashIndex; i++ {
+		if entryName[i] != '-' {
+			continue
+		}
+		name, version := entryName[:i], entryName[i+1:hashIndex]
+		if scope != "" {
+			name = scope + "/" + name
+		}
+		if filepath.Base(c.getPackagePath(name, version)) == entryName {
+			return name, version, true
+		}
+	}
+	return "", "", false
 }
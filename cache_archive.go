@@ -0,0 +1,45 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+)
+
+const tarballExt = ".tgz"
+
+func (c *Cache) tarballPath(name, version string) string {
+	return c.getPackagePath(name, version) + tarballExt
+}
+
+func (c *Cache) extractTarball(name, version, destPath string, workers int) error {
+	file, err := os.Open(c.tarballPath(name, version))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+
+	if err := extractPackage(tar.NewReader(gzipReader), destPath, workers); err != nil {
+		gzipReader.Close()
+		return err
+	}
+	return gzipReader.Close()
+}
+
+func (c *Cache) withPackageTree(name, version string, fn func(treePath string) error) error {
+	tmpDir, err := os.MkdirTemp("", "gpm-cache-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := c.extractTarball(name, version, tmpDir, defaultExtractWorkers()); err != nil {
+		return err
+	}
+	return fn(tmpDir)
+}
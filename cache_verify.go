@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type cacheProblem struct {
+	Label   string
+	Problem string
+	remove  func() error
+}
+
+func tarballIntegrity(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := newIntegrityHasher()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hasher.Integrity(), nil
+}
+
+func (c *Cache) verifyEntry(pkg CachedPackage) string {
+	if recorded := c.getIntegrity(pkg.Name, pkg.Version); recorded != "" {
+		actual, err := tarballIntegrity(pkg.Path)
+		if err != nil {
+			return fmt.Sprintf("tarball is unreadable: %v", err)
+		}
+		if !integrityMatches(recorded, actual) {
+			return fmt.Sprintf("integrity mismatch: expected %s, got %s", strongestIntegrity(recorded), matchingDigest(recorded, actual))
+		}
+	}
+	return inspectStoreEntry(c, pkg.Name, pkg.Version).Problem
+}
+
+func (c *Cache) verify() (int, []cacheProblem, error) {
+	packages, err := c.listPackages()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var problems []cacheProblem
+	for _, pkg := range packages {
+		if problem := c.verifyEntry(pkg); problem != "" {
+			name, version := pkg.Name, pkg.Version
+			problems = append(problems, cacheProblem{
+				Label:   name + "@" + version,
+				Problem: problem,
+				remove: func() error {
+					_, err := c.removePackage(name, version)
+					return err
+				},
+			})
+		}
+	}
+
+	leftovers, err := c.leftoverFiles()
+	return len(packages), append(problems, leftovers...), err
+}
+
+func (c *Cache) leftoverFiles() ([]cacheProblem, error) {
+	dirs := []string{c.cacheDir}
+	entries, err := os.ReadDir(c.cacheDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "@") {
+			dirs = append(dirs, filepath.Join(c.cacheDir, entry.Name()))
+		}
+	}
+
+	var leftovers []cacheProblem
+	add := func(path, problem string) {
+		label, _ := filepath.Rel(c.cacheDir, path)
+		leftovers = append(leftovers, cacheProblem{
+			Label:   label,
+			Problem: problem,
+			remove: func() error {
+				return os.RemoveAll(path)
+			},
+		})
+	}
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return leftovers, err
+		}
+
+		scope := ""
+		if dir != c.cacheDir {
+			scope = filepath.Base(dir)
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			switch {
+			case strings.HasPrefix(entry.Name(), "."):
+				add(path, "left behind by an interrupted install")
+			case strings.HasSuffix(entry.Name(), ".integrity"):
+				name, version, ok := c.parseEntryName(scope, strings.TrimSuffix(entry.Name(), ".integrity"))
+				if ok && !c.hasPackage(name, version) {
+					add(path, "integrity record without a package")
+				}
+			}
+		}
+	}
+	return leftovers, nil
+}
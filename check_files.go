@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+func (pm *PackageManager) isInstalled(packagePath, registryName, version string) bool {
+	if !pm.isPackageInstalled(packagePath, version) {
+		return false
+	}
+	return !pm.checkFiles || pm.missingPackageFile(packagePath, registryName, version) == ""
+}
+
+func (pm *PackageManager) hasInstalledDependency(name string) bool {
+	packagePath := filepath.Join(pm.nodeModulesPath, name)
+	if !pm.checkFiles {
+		return fileExists(packagePath)
+	}
+
+	manifest, err := readInstalledManifest(packagePath)
+	if err != nil {
+		return false
+	}
+
+	registryName := manifest.Name
+	if registryName == "" {
+		registryName = name
+	}
+	return pm.isInstalled(packagePath, registryName, manifest.Version)
+}
+
+func (pm *PackageManager) missingPackageFile(packagePath, registryName, version string) string {
+	if pm.cache.hasPackage(registryName, version) {
+		var missing string
+		err := pm.cache.withPackageTree(registryName, version, func(cachePath string) error {
+			missing = missingCachedFile(packagePath, cachePath)
+			return nil
+		})
+		if err == nil {
+			return missing
+		}
+	}
+	return missingManifestFile(packagePath)
+}
+
+func missingCachedFile(packagePath, cachePath string) string {
+	var missing string
+
+	filepath.Walk(cachePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(cachePath, path)
+		if err != nil {
+			return err
+		}
+
+		installed, err := os.Stat(filepath.Join(packagePath, relPath))
+		if err != nil || installed.Size() != info.Size() {
+			missing = relPath
+			return filepath.SkipAll
+		}
+		return nil
+	})
+
+	return missing
+}
+
+func missingManifestFile(packagePath string) string {
+	data, err := os.ReadFile(filepath.Join(packagePath, "package.json"))
+	if err != nil {
+		return "package.json"
+	}
+
+	var pkg struct {
+		Main string   `json:"main"`
+		Bin  binField `json:"bin"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "package.json"
+	}
+
+	var files []string
+	if pkg.Main != "" {
+		files = append(files, pkg.Main)
+	}
+	for _, path := range pkg.Bin.binaries("") {
+		files = append(files, path)
+	}
+
+	for _, file := range files {
+		path := filepath.Join(packagePath, file)
+		if fileExists(path) || fileExists(path+".js") || fileExists(filepath.Join(path, "index.js")) {
+			continue
+		}
+		return file
+	}
+
+	return ""
+}
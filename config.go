@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	values map[string]string
+}
+
+const configFileName = ".gpmrc"
+
+const npmConfigFileName = ".npmrc"
+
+var npmConfigKeys = map[string]bool{
+	"save-prefix": true,
+	"save-exact":  true,
+}
+
+var config = &Config{values: make(map[string]string)}
+
+func loadConfig() *Config {
+	cfg := &Config{values: make(map[string]string)}
+
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		cfg.loadFile(filepath.Join(homeDir, npmConfigFileName), npmConfigKeys)
+		cfg.loadFile(filepath.Join(homeDir, configFileName), nil)
+	}
+	cfg.loadFile(npmConfigFileName, npmConfigKeys)
+	cfg.loadFile(configFileName, nil)
+
+	return cfg
+}
+
+func (c *Config) loadFile(path string, keys map[string]bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if keys != nil {
+			if !keys[key] && !isRegistryConfigKey(key) {
+				continue
+			}
+			value = os.ExpandEnv(value)
+		}
+
+		c.values[key] = value
+	}
+}
+
+func (c *Config) get(key string) string {
+	return c.values[key]
+}
+
+func (c *Config) getDefault(key, fallback string) string {
+	if value, ok := c.values[key]; ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (c *Config) getBool(key string, fallback bool) bool {
+	value, ok := c.values[key]
+	if !ok {
+		return fallback
+	}
+	return parseBool(value, fallback)
+}
+
+func parseBool(value string, fallback bool) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1", "yes":
+		return true
+	case "false", "0", "no":
+		return false
+	}
+	return fallback
+}
+
+func (c *Config) getInt(key string, fallback int) int {
+	value, ok := c.values[key]
+	if !ok {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 1 {
+		return fallback
+	}
+	return n
+}
+
+func (c *Config) getDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := c.values[key]
+	if !ok {
+		return fallback
+	}
+
+	duration, err := parseDuration(value)
+	if err != nil {
+		return fallback
+	}
+	return duration
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid duration %q (expected seconds or a value like 30s or 5m)", value)
+	}
+	return duration, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD or an RFC 3339 timestamp)", value)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type DedupeCandidate struct {
+	Name          string   `json:"name"`
+	Versions      []string `json:"versions"`
+	Target        string   `json:"target,omitempty"`
+	Ranges        []string `json:"ranges"`
+	RemovedCopies int      `json:"removedCopies,omitempty"`
+	RemovedLocked int      `json:"removedLockEntries,omitempty"`
+	SavedBytes    int64    `json:"savedBytes,omitempty"`
+}
+
+type DedupeReport struct {
+	Collapsible []DedupeCandidate `json:"collapsible"`
+	Blocked     []DedupeCandidate `json:"blocked"`
+	SavedBytes  int64             `json:"savedBytes"`
+	Copies      int               `json:"removedCopies"`
+	LockEntries int               `json:"removedLockEntries"`
+}
+
+func buildDedupeReport(nodeModulesPath string, pkg *PackageJSON, lockFile *LockFile) (*DedupeReport, error) {
+	scanner := scanDuplicates(nodeModulesPath, pkg, lockFile)
+	report := &DedupeReport{Collapsible: []DedupeCandidate{}, Blocked: []DedupeCandidate{}}
+
+	names := make([]string, 0, len(scanner.versions))
+	for name, versions := range scanner.versions {
+		if len(versions) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		versions := scanner.versions[name]
+		candidate := DedupeCandidate{Name: name}
+
+		ranges := make(map[string]bool)
+		for version, entry := range versions {
+			candidate.Versions = append(candidate.Versions, version)
+			for depRange := range entry.ranges {
+				ranges[depRange] = true
+			}
+		}
+		sort.Slice(candidate.Versions, func(i, j int) bool {
+			return compareVersions(candidate.Versions[i], candidate.Versions[j]) < 0
+		})
+		candidate.Ranges = sortedSet(ranges)
+
+		for i := len(candidate.Versions) - 1; i >= 0 && candidate.Target == ""; i-- {
+			if satisfiesAllRanges(candidate.Versions[i], candidate.Ranges) {
+				candidate.Target = candidate.Versions[i]
+			}
+		}
+		if candidate.Target == "" {
+			report.Blocked = append(report.Blocked, candidate)
+			continue
+		}
+
+		for version, entry := range versions {
+			if version == candidate.Target {
+				continue
+			}
+			if lockFile.hasPackage(name, version) {
+				candidate.RemovedLocked++
+			}
+			for path := range entry.paths {
+				size, err := packageOwnSize(path)
+				if err != nil {
+					return nil, err
+				}
+				candidate.SavedBytes += size
+				candidate.RemovedCopies++
+			}
+		}
+
+		report.Collapsible = append(report.Collapsible, candidate)
+		report.SavedBytes += candidate.SavedBytes
+		report.Copies += candidate.RemovedCopies
+		report.LockEntries += candidate.RemovedLocked
+	}
+
+	return report, nil
+}
+
+func satisfiesAllRanges(version string, ranges []string) bool {
+	for _, depRange := range ranges {
+		if !satisfiesRange(version, depRange) {
+			return false
+		}
+	}
+	return true
+}
+
+func packageOwnSize(packagePath string) (int64, error) {
+	var size int64
+	err := filepath.Walk(filepath.FromSlash(packagePath), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == "node_modules" {
+			return filepath.SkipDir
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure %s: %v", packagePath, err)
+	}
+	return size, nil
+}
+
+func printDedupeReportJSON(report *DedupeReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dedupe report: %v", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func printDedupeReport(report *DedupeReport) {
+	if len(report.Collapsible) == 0 && len(report.Blocked) == 0 {
+		fmt.Printf(" %s No duplicate versions to dedupe\n", color.HiGreenString("✓"))
+		return
+	}
+
+	if len(report.Collapsible) > 0 {
+		fmt.Printf(" %s %d package(s) could be deduped:\n", color.CyanString("ℹ"), len(report.Collapsible))
+		for _, candidate := range report.Collapsible {
+			fmt.Printf("   %s %s %s %s %s\n",
+				color.CyanString(candidate.Name),
+				color.HiBlackString(strings.Join(candidate.Versions, ", ")),
+				color.BlueString("→"),
+				color.GreenString(candidate.Target),
+				color.HiBlackString("(%d copies, %d lock entries, %s)", candidate.RemovedCopies, candidate.RemovedLocked, formatBytes(candidate.SavedBytes)))
+		}
+	}
+
+	if len(report.Blocked) > 0 {
+		fmt.Printf(" %s %d package(s) cannot be deduped (no version satisfies every range):\n", color.YellowString("⚠"), len(report.Blocked))
+		for _, candidate := range report.Blocked {
+			fmt.Printf("   %s %s %s\n",
+				color.CyanString(candidate.Name),
+				color.HiBlackString(strings.Join(candidate.Versions, ", ")),
+				color.HiBlackString("needs %s", strings.Join(candidate.Ranges, ", ")))
+		}
+	}
+
+	fmt.Printf(" %s Estimated savings: %s, %d installed copies, %d lock entries\n",
+		color.HiGreenString("→"), formatBytes(report.SavedBytes), report.Copies, report.LockEntries)
+	fmt.Printf(" %s Report only: nothing was changed\n", color.HiBlackString("ℹ"))
+}
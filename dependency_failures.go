@@ -0,0 +1,70 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+type dependencyFailure struct {
+	Parent     string
+	Dependency string
+	Version    string
+	Err        error
+}
+
+type dependencyFailureLog struct {
+	mu       sync.Mutex
+	failures []dependencyFailure
+	reported int
+}
+
+func (pm *PackageManager) recordDependencyFailure(parent, dependency, version string, err error) {
+	log := &pm.dependencyFailures
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	log.failures = append(log.failures, dependencyFailure{Parent: parent, Dependency: dependency, Version: version, Err: err})
+}
+
+func (pm *PackageManager) hasDependencyFailures() bool {
+	log := &pm.dependencyFailures
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	return len(log.failures) > 0
+}
+
+func (pm *PackageManager) reportDependencyFailures() {
+	log := &pm.dependencyFailures
+	log.mu.Lock()
+	pending := log.failures[log.reported:]
+	log.reported = len(log.failures)
+	log.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	if !reporter.Human() {
+		for _, failure := range pending {
+			reporter.Report(InstallEvent{
+				Type:    "failed",
+				Package: failure.Dependency,
+				Version: failure.Version,
+				Message: "required by " + failure.Parent,
+				Error:   failure.Err.Error(),
+			})
+		}
+		return
+	}
+
+	output.Printf(" %s %d transitive dependencies failed to install:\n", color.YellowString("⚠"), len(pending))
+	for _, failure := range pending {
+		output.Printf("   %s %s %s: %v\n",
+			color.CyanString(failure.Parent),
+			color.HiBlackString("→"),
+			color.CyanString("%s@%s", failure.Dependency, failure.Version),
+			failure.Err)
+	}
+}
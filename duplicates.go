@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+type DuplicateVersion struct {
+	Version    string   `json:"version"`
+	Paths      []string `json:"paths,omitempty"`
+	RequiredBy []string `json:"requiredBy,omitempty"`
+}
+
+type duplicateScanner struct {
+	nodeModulesPath string
+	versions        map[string]map[string]*duplicateEntry
+}
+
+type duplicateEntry struct {
+	paths   map[string]bool
+	parents map[string]bool
+	ranges  map[string]bool
+}
+
+func scanDuplicates(nodeModulesPath string, pkg *PackageJSON, lockFile *LockFile) *duplicateScanner {
+	scanner := &duplicateScanner{
+		nodeModulesPath: nodeModulesPath,
+		versions:        make(map[string]map[string]*duplicateEntry),
+	}
+
+	scanner.scanInstalled(nodeModulesPath, make(map[string]bool))
+	scanner.scanLockFile(lockFile)
+	scanner.addRootParents(pkg)
+	return scanner
+}
+
+func findDuplicates(nodeModulesPath string, pkg *PackageJSON, lockFile *LockFile) map[string][]DuplicateVersion {
+	scanner := scanDuplicates(nodeModulesPath, pkg, lockFile)
+
+	duplicates := make(map[string][]DuplicateVersion)
+	for name, versions := range scanner.versions {
+		if len(versions) < 2 {
+			continue
+		}
+
+		list := make([]DuplicateVersion, 0, len(versions))
+		for version, entry := range versions {
+			list = append(list, DuplicateVersion{
+				Version:    version,
+				Paths:      sortedSet(entry.paths),
+				RequiredBy: sortedSet(entry.parents),
+			})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return compareVersions(list[i].Version, list[j].Version) < 0
+		})
+		duplicates[name] = list
+	}
+	return duplicates
+}
+
+func (s *duplicateScanner) require(name, version, parent, depRange string) {
+	entry := s.entry(name, version)
+	entry.parents[fmt.Sprintf("%s (%s)", parent, depRange)] = true
+	entry.ranges[depRange] = true
+}
+
+func (s *duplicateScanner) entry(name, version string) *duplicateEntry {
+	if s.versions[name] == nil {
+		s.versions[name] = make(map[string]*duplicateEntry)
+	}
+	entry, ok := s.versions[name][version]
+	if !ok {
+		entry = &duplicateEntry{paths: make(map[string]bool), parents: make(map[string]bool), ranges: make(map[string]bool)}
+		s.versions[name][version] = entry
+	}
+	return entry
+}
+
+func (s *duplicateScanner) scanInstalled(dir string, visited map[string]bool) {
+	packages, err := listInstalledPackages(dir)
+	if err != nil {
+		return
+	}
+
+	for _, name := range packages {
+		packagePath := filepath.Join(dir, name)
+		if visited[packagePath] {
+			continue
+		}
+		visited[packagePath] = true
+
+		manifest, err := readInstalledManifest(packagePath)
+		if err != nil || manifest.Version == "" {
+			continue
+		}
+		s.entry(name, manifest.Version).paths[filepath.ToSlash(packagePath)] = true
+
+		parent := fmt.Sprintf("%s@%s", name, manifest.Version)
+		for _, deps := range []map[string]string{manifest.Dependencies, manifest.OptionalDependencies} {
+			for depName, depRange := range deps {
+				depPath := resolveInstalledDependency(s.nodeModulesPath, packagePath, depName)
+				if depPath == "" {
+					continue
+				}
+				if depManifest, err := readInstalledManifest(depPath); err == nil && depManifest.Version != "" {
+					s.require(depName, depManifest.Version, parent, depRange)
+				}
+			}
+		}
+
+		s.scanInstalled(filepath.Join(packagePath, "node_modules"), visited)
+	}
+}
+
+func (s *duplicateScanner) scanLockFile(lockFile *LockFile) {
+	lockFile.mu.RLock()
+	defer lockFile.mu.RUnlock()
+
+	byName := make(map[string][]string)
+	for _, lockPkg := range lockFile.Packages {
+		if lockPkg.Skipped {
+			continue
+		}
+		s.entry(lockPkg.Name, lockPkg.Version)
+		byName[lockPkg.Name] = append(byName[lockPkg.Name], lockPkg.Version)
+	}
+
+	for _, lockPkg := range lockFile.Packages {
+		if lockPkg.Skipped {
+			continue
+		}
+		parent := fmt.Sprintf("%s@%s", lockPkg.Name, lockPkg.Version)
+		for depName, depRange := range lockPkg.Dependencies {
+			for _, version := range byName[depName] {
+				if satisfiesRange(version, depRange) {
+					s.require(depName, version, parent, depRange)
+				}
+			}
+		}
+	}
+}
+
+func (s *duplicateScanner) addRootParents(pkg *PackageJSON) {
+	root := pkg.Name
+	if root == "" {
+		root = "(root)"
+	}
+
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.OptionalDependencies} {
+		for name, depRange := range deps {
+			hoisted := filepath.ToSlash(filepath.Join(s.nodeModulesPath, name))
+			installed := ""
+			for version, entry := range s.versions[name] {
+				if entry.paths[hoisted] {
+					installed = version
+				}
+			}
+
+			for version := range s.versions[name] {
+				if version == installed || (installed == "" && satisfiesRange(version, depRange)) {
+					s.require(name, version, root, depRange)
+				}
+			}
+		}
+	}
+}
+
+func sortedSet(set map[string]bool) []string {
+	values := make([]string, 0, len(set))
+	for value := range set {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func printDuplicatesJSON(duplicates map[string][]DuplicateVersion) error {
+	data, err := json.MarshalIndent(duplicates, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal duplicates: %v", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func printDuplicates(duplicates map[string][]DuplicateVersion) {
+	if len(duplicates) == 0 {
+		fmt.Printf(" %s No package is installed at more than one version\n", color.HiGreenString("✓"))
+		return
+	}
+
+	names := make([]string, 0, len(duplicates))
+	for name := range duplicates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf(" %s %d package(s) have multiple versions:\n", color.YellowString("⚠"), len(names))
+	for _, name := range names {
+		fmt.Printf("\n   %s\n", color.CyanString(name))
+		for _, version := range duplicates[name] {
+			if len(version.Paths) == 0 {
+				fmt.Printf("     %s %s\n", color.YellowString(version.Version), color.HiBlackString("(lockfile only)"))
+			} else {
+				fmt.Printf("     %s\n", color.YellowString(version.Version))
+			}
+			for _, path := range version.Paths {
+				fmt.Printf("       %s\n", color.HiBlackString(path))
+			}
+			for _, parent := range version.RequiredBy {
+				fmt.Printf("       %s %s\n", color.HiBlackString("required by"), parent)
+			}
+		}
+	}
+	fmt.Println()
+}
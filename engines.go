@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+type engineMismatchError struct {
+	name     string
+	version  string
+	engine   string
+	required string
+	active   string
+}
+
+func (e *engineMismatchError) Error() string {
+	return fmt.Sprintf("%s@%s requires %s %s but the active %s is v%s", e.name, e.version, e.engine, e.required, e.engine, e.active)
+}
+
+var (
+	engineNodeOnce    sync.Once
+	engineNodeVersion string
+)
+
+func currentNodeVersion() string {
+	engineNodeOnce.Do(func() {
+		engineNodeVersion = activeNodeVersion()
+	})
+	return engineNodeVersion
+}
+
+func (pm *PackageManager) checkEngines(pkgInfo *PackageInfo) error {
+	if pm.ignoreEngines {
+		return nil
+	}
+	return checkEngineRanges(pkgInfo.Name, pkgInfo.Version, pkgInfo.Engines, pm.engineStrict)
+}
+
+func checkEngineRanges(name, version string, engines map[string]string, strict bool) error {
+	for _, engine := range []string{"node", "gpm"} {
+		required := strings.TrimSpace(engines[engine])
+		if required == "" || required == "*" {
+			continue
+		}
+
+		active := currentNodeVersion()
+		if engine == "gpm" {
+			active = currentGpmVersion()
+			if active == "dev" {
+				continue
+			}
+		}
+		if active == "" || satisfiesRange(active, required) {
+			continue
+		}
+
+		err := &engineMismatchError{name: name, version: version, engine: engine, required: required, active: active}
+		if strict {
+			return err
+		}
+		reportWarning("%v", err)
+	}
+	return nil
+}
+
+func checkProjectEngines() {
+	if config.getBool("ignore-engines", false) || hasFlag("--ignore-engines") || !fileExists("package.json") {
+		return
+	}
+
+	pkg, err := loadPackageJSON("package.json")
+	if err != nil || len(pkg.Engines) == 0 {
+		return
+	}
+
+	name := pkg.Name
+	if name == "" {
+		name = "package.json"
+	}
+	strict := config.getBool("engine-strict", false) || hasFlag("--engine-strict")
+	if err := checkEngineRanges(name, pkg.Version, map[string]string{"gpm": pkg.Engines["gpm"]}, strict); err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+var execArgs []string
+
+func splitExecArgs(args []string) ([]string, []string) {
+	if len(args) <= 3 {
+		return args, nil
+	}
+	return args[:3], args[3:]
+}
+
+func (bm *BinaryManager) binaryCommand(name string, args []string) *exec.Cmd {
+	target := filepath.Join(bm.binPath, name)
+	if runtime.GOOS == "windows" && fileExists(target+".cmd") {
+		target += ".cmd"
+	}
+
+	cmd := exec.Command(target, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "PATH="+scriptPath())
+	return cmd
+}
+
+func runBinary(cmd *exec.Cmd) (int, error) {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if code := exitErr.ExitCode(); code >= 0 {
+			return code, nil
+		}
+		return 1, nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+func closestMatch(name string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if strings.Contains(candidate, name) || strings.Contains(name, candidate) {
+			distance = min(distance, 1)
+		}
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	if bestDistance < 0 || bestDistance > max(2, len(name)/3) {
+		return ""
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+)
+
+const maxBufferedExtractSize = 4 << 20
+
+type extractPool struct {
+	workers int
+	sem     chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	err     error
+}
+
+func defaultExtractWorkers() int {
+	workers := runtime.NumCPU()
+	if workers > 8 {
+		workers = 8
+	}
+	return workers
+}
+
+func newExtractPool(workers int) *extractPool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &extractPool{
+		workers: workers,
+		sem:     make(chan struct{}, workers),
+	}
+}
+
+func (p *extractPool) submit(fn func() error) {
+	p.sem <- struct{}{}
+	p.wg.Add(1)
+
+	go func() {
+		defer func() {
+			<-p.sem
+			p.wg.Done()
+		}()
+
+		if err := fn(); err != nil {
+			p.mu.Lock()
+			if p.err == nil {
+				p.err = err
+			}
+			p.mu.Unlock()
+		}
+	}()
+}
+
+func (p *extractPool) failed() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+func (p *extractPool) wait() error {
+	p.wg.Wait()
+	return p.failed()
+}
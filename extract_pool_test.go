@@ -0,0 +1,39 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func BenchmarkExtractPackage(b *testing.B) {
+	var files []string
+	content := strings.Repeat("module.exports = require('./lib');\n", 512)
+	for i := 0; i < 2000; i++ {
+		files = append(files, fmt.Sprintf("lib/dir%d/file%d.js", i%50, i), content)
+	}
+	tarball := packageTar(b, files...)
+
+	for _, bench := range []struct {
+		name    string
+		workers int
+	}{
+		{"serial", 1},
+		{"default", defaultExtractWorkers()},
+	} {
+		workers := bench.workers
+		b.Run(fmt.Sprintf("%s/workers=%d", bench.name, workers), func(b *testing.B) {
+			b.SetBytes(int64(len(tarball)))
+			root := b.TempDir()
+			for i := 0; i < b.N; i++ {
+				dest := filepath.Join(root, fmt.Sprintf("pkg-%d", i))
+				if err := extractPackage(tar.NewReader(bytes.NewReader(tarball)), dest, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+var foreignModulesMarkers = []struct {
+	Tool string
+	File string
+}{
+	{"npm", ".package-lock.json"},
+	{"yarn", ".yarn-state.yml"},
+	{"yarn", ".yarn-integrity"},
+	{"pnpm", ".modules.yaml"},
+}
+
+func detectForeignNodeModules(nodeModulesPath string) (string, string) {
+	for _, marker := range foreignModulesMarkers {
+		path := filepath.Join(nodeModulesPath, marker.File)
+		if fileExists(path) {
+			return marker.Tool, path
+		}
+	}
+	return "", ""
+}
+
+func preflightForeignNodeModules(nodeModulesPath string, clean bool) error {
+	tool, marker := detectForeignNodeModules(nodeModulesPath)
+	if tool == "" {
+		return nil
+	}
+
+	if !clean && reporter.Human() && interactiveOutput && isatty.IsTerminal(os.Stdin.Fd()) {
+		fmt.Printf(" %s %s was created by %s (found %s); its layout can conflict with gpm's\n",
+			color.YellowString("⚠"), nodeModulesPath, tool, filepath.Base(marker))
+		clean = NewTUI().ConfirmAction(fmt.Sprintf("Remove %s and install fresh?", nodeModulesPath))
+		if !clean {
+			return nil
+		}
+	}
+
+	if !clean {
+		reportWarning("%s was created by %s (found %s); its layout can conflict with gpm's, run gpm install --clean to reset it", nodeModulesPath, tool, filepath.Base(marker))
+		return nil
+	}
+
+	if err := os.RemoveAll(nodeModulesPath); err != nil {
+		return fmt.Errorf("failed to remove %s: %v", nodeModulesPath, err)
+	}
+	if reporter.Human() {
+		fmt.Printf(" %s %s %s\n", color.HiGreenString("✓"), nodeModulesPath, color.RedString("removed"))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type lockfileMismatch struct {
+	Name     string
+	Manifest string
+	Locked   string
+	Reason   string
+}
+
+func lockedSpecifierRange(name, specifier string) string {
+	if specifier == name {
+		return ""
+	}
+	return strings.TrimPrefix(specifier, name+"@")
+}
+
+func checkFrozenLockfile(pkg *PackageJSON, lockFile *LockFile) []lockfileMismatch {
+	lockFile.mu.RLock()
+	defer lockFile.mu.RUnlock()
+
+	locked := make(map[string]LockPackage)
+	for _, lockPkg := range lockFile.Packages {
+		locked[lockPkg.Name] = lockPkg
+	}
+
+	declared := make(map[string]string)
+	for _, deps := range []map[string]string{pkg.DevDependencies, pkg.Dependencies, pkg.OptionalDependencies} {
+		for name, depRange := range deps {
+			declared[name] = depRange
+		}
+	}
+
+	var mismatches []lockfileMismatch
+	for _, name := range sortedKeys(declared) {
+		depRange := declared[name]
+		specifier, hasSpecifier := lockFile.Specifiers[name]
+		lockPkg, hasPackage := locked[name]
+
+		switch {
+		case !hasSpecifier || !hasPackage:
+			mismatches = append(mismatches, lockfileMismatch{Name: name, Manifest: depRange, Reason: "not in lockfile"})
+		case lockedSpecifierRange(name, specifier) != "" && lockedSpecifierRange(name, specifier) != depRange:
+			mismatches = append(mismatches, lockfileMismatch{Name: name, Manifest: depRange, Locked: lockedSpecifierRange(name, specifier), Reason: "specifier changed"})
+		case !lockPkg.Skipped && !satisfiesRange(lockPkg.Version, depRange):
+			mismatches = append(mismatches, lockfileMismatch{Name: name, Manifest: depRange, Locked: lockPkg.Version, Reason: "locked version does not satisfy the range"})
+		}
+	}
+
+	var removed []string
+	for _, lockPkg := range lockFile.Packages {
+		if _, ok := declared[lockPkg.Name]; lockPkg.Direct && !ok {
+			removed = append(removed, lockPkg.Name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		mismatches = append(mismatches, lockfileMismatch{Name: name, Locked: locked[name].Version, Reason: "removed from package.json"})
+	}
+
+	return mismatches
+}
+
+func printLockfileMismatches(mismatches []lockfileMismatch) {
+	fmt.Printf(" %s %s is out of date with package.json:\n", color.RedString("✗"), lockFileName())
+	for _, mismatch := range mismatches {
+		switch {
+		case mismatch.Locked == "":
+			fmt.Printf("   %s %s %s %s\n", color.GreenString("+"), color.CyanString(mismatch.Name), mismatch.Manifest, color.HiBlackString("(%s)", mismatch.Reason))
+		case mismatch.Manifest == "":
+			fmt.Printf("   %s %s %s %s\n", color.RedString("-"), color.CyanString(mismatch.Name), mismatch.Locked, color.HiBlackString("(%s)", mismatch.Reason))
+		default:
+			fmt.Printf("   %s %s %s %s %s %s\n", color.YellowString("~"), color.CyanString(mismatch.Name), color.RedString(mismatch.Locked), color.BlueString("→"), color.GreenString(mismatch.Manifest), color.HiBlackString("(%s)", mismatch.Reason))
+		}
+	}
+	fmt.Printf(" %s Run gpm install without --frozen-lockfile to update %s\n", color.HiBlackString("ℹ"), lockFileName())
+}
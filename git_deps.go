@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type gitSpec struct {
+	Spec        string
+	URL         string
+	Ref         string
+	SemverRange string
+}
+
+type gitDependency struct {
+	Name     string
+	Spec     string
+	IsDev    bool
+	Optional bool
+}
+
+var githubShorthandPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+(#.*)?$`)
+
+func isGitSpec(spec string) bool {
+	for _, prefix := range []string{"git+", "git://", "github:"} {
+		if strings.HasPrefix(spec, prefix) {
+			return true
+		}
+	}
+	return !strings.HasPrefix(spec, "@") && githubShorthandPattern.MatchString(spec)
+}
+
+func parseGitSpec(spec string) (*gitSpec, error) {
+	parsed := &gitSpec{Spec: spec}
+
+	location, fragment, _ := strings.Cut(spec, "#")
+	switch {
+	case strings.HasPrefix(location, "git+"):
+		parsed.URL = strings.TrimPrefix(location, "git+")
+	case strings.HasPrefix(location, "git://"):
+		parsed.URL = location
+	default:
+		repo := strings.TrimPrefix(location, "github:")
+		if !githubShorthandPattern.MatchString(repo) {
+			return nil, fmt.Errorf("invalid git dependency: %s", spec)
+		}
+		parsed.URL = "https://github.com/" + strings.TrimSuffix(repo, ".git") + ".git"
+	}
+	if parsed.URL == "" || strings.HasPrefix(parsed.URL, "-") {
+		return nil, fmt.Errorf("invalid git dependency: %s", spec)
+	}
+
+	if strings.HasPrefix(fragment, "semver:") {
+		parsed.SemverRange = strings.TrimPrefix(fragment, "semver:")
+		if parsed.SemverRange == "" {
+			return nil, fmt.Errorf("empty semver range in %s", spec)
+		}
+	} else {
+		parsed.Ref = fragment
+	}
+
+	return parsed, nil
+}
+
+func (g *gitSpec) resolve() (string, string, error) {
+	if g.SemverRange != "" {
+		return g.resolveSemverTag()
+	}
+
+	ref := g.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if isCommitHash(ref) {
+		return ref, "", nil
+	}
+
+	refs, err := lsRemote(g.URL, false, ref)
+	if err != nil {
+		return "", "", err
+	}
+	for _, candidate := range []string{ref, "refs/heads/" + ref, "refs/tags/" + ref + "^{}", "refs/tags/" + ref} {
+		if commit, ok := refs[candidate]; ok {
+			return commit, "", nil
+		}
+	}
+	return "", "", fmt.Errorf("ref %s not found in %s", ref, redactSecrets(g.URL))
+}
+
+func (g *gitSpec) resolveSemverTag() (string, string, error) {
+	refs, err := lsRemote(g.URL, true)
+	if err != nil {
+		return "", "", err
+	}
+
+	tags := make(map[string]string)
+	available := make(map[string]PackageInfo)
+	for ref, commit := range refs {
+		tag := strings.TrimPrefix(ref, "refs/tags/")
+		peeled := strings.HasSuffix(tag, "^{}")
+		tag = strings.TrimSuffix(tag, "^{}")
+
+		version, err := validateVersion(tag)
+		if err != nil {
+			continue
+		}
+		if _, seen := tags[version]; seen && !peeled {
+			continue
+		}
+		tags[version] = commit
+		available[version] = PackageInfo{Version: version}
+	}
+
+	version := resolveVersionRange(g.SemverRange, available)
+	if version == "" {
+		return "", "", fmt.Errorf("no tag in %s satisfies %s", redactSecrets(g.URL), g.SemverRange)
+	}
+	return tags[version], version, nil
+}
+
+func isCommitHash(ref string) bool {
+	if len(ref) != 40 {
+		return false
+	}
+	for _, c := range ref {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+func lsRemote(url string, tagsOnly bool, patterns ...string) (map[string]string, error) {
+	args := []string{"ls-remote"}
+	if tagsOnly {
+		args = append(args, "--tags")
+	}
+	args = append(append(args, "--", url), patterns...)
+
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-remote failed: %v", redactSecrets(gitError(err).Error()))
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		commit, name, ok := strings.Cut(line, "\t")
+		if ok {
+			refs[name] = commit
+		}
+	}
+	return refs, nil
+}
+
+func gitError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+func checkoutGitCommit(url, commit string) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "gpm-git-")
+	if err != nil {
+		return "", err
+	}
+
+	for _, step := range []struct {
+		name string
+		args []string
+	}{
+		{"clone", []string{"clone", "--quiet", "--no-checkout", "--", url, tmpDir}},
+		{"checkout", []string{"-C", tmpDir, "checkout", "--quiet", commit, "--"}},
+	} {
+		if out, err := exec.Command("git", step.args...).CombinedOutput(); err != nil {
+			os.RemoveAll(tmpDir)
+			return "", fmt.Errorf("git %s failed: %s", step.name, redactSecrets(strings.TrimSpace(string(out))))
+		}
+	}
+
+	if err := os.RemoveAll(filepath.Join(tmpDir, ".git")); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", err
+	}
+	return tmpDir, nil
+}
+
+func installGitPackage(pm *PackageManager, lockFile *LockFile, spec, lockedCommit string, isDev bool, writeToPackageJSON bool) (string, error) {
+	parsed, err := parseGitSpec(spec)
+	if err != nil {
+		return "", err
+	}
+	if pm.offline {
+		return "", fmt.Errorf("%s is a git dependency; it cannot be installed with --offline", spec)
+	}
+
+	commit, tagVersion := lockedCommit, ""
+	if commit == "" {
+		commit, tagVersion, err = parsed.resolve()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	checkout, err := checkoutGitCommit(parsed.URL, commit)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(checkout)
+
+	manifest, err := readInstalledManifest(checkout)
+	if err != nil {
+		return "", fmt.Errorf("%s has no valid package.json: %v", spec, err)
+	}
+	if manifest.Name == "" {
+		return "", fmt.Errorf("%s has no package name", spec)
+	}
+
+	version := manifest.Version
+	if version == "" {
+		version = tagVersion
+	}
+
+	packagePath := filepath.Join(pm.nodeModulesPath, manifest.Name)
+	if err := os.RemoveAll(packagePath); err != nil {
+		return "", err
+	}
+	if err := copyDirectory(checkout, packagePath); err != nil {
+		return "", fmt.Errorf("failed to install %s: %v", manifest.Name, err)
+	}
+	if err := normalizeTimes(packagePath); err != nil {
+		return "", err
+	}
+
+	if err := lockFile.addPackage(manifest.Name, version, spec, isDev); err != nil {
+		return "", err
+	}
+	lockFile.setResolution(manifest.Name, version, "git+"+parsed.URL+"#"+commit, "")
+
+	if writeToPackageJSON {
+		if err := updatePackageJSON(manifest.Name, spec, isDev); err != nil {
+			reportWarning("Failed to update package.json: %v", err)
+		}
+	}
+
+	if err := pm.InstallDependencies(manifest.Name, lockFile); err != nil {
+		reportWarning("Failed to install some dependencies for %s: %v", manifest.Name, err)
+	}
+
+	if err := NewBinaryManager().setupPackageBinaries(manifest.Name); err != nil {
+		reportWarning("Failed to setup binaries for %s: %v", manifest.Name, err)
+	}
+
+	output.Printf(" %s %s@%s %s\n", color.HiGreenString("✓"), color.CyanString(manifest.Name), color.HiBlackString(version), color.HiBlackString("(%s)", shortCommit(commit)))
+	return manifest.Name, nil
+}
+
+func installGitDependency(pm *PackageManager, lockFile *LockFile, name, spec string, isDev bool) error {
+	lockedCommit := ""
+	if lockFile.Specifiers[name] == spec {
+		version := lockFile.getPackageVersion(name)
+		if pkg, ok := lockFile.Packages[name+"@"+version]; ok && strings.HasPrefix(pkg.Resolved, "git+") {
+			_, lockedCommit, _ = strings.Cut(pkg.Resolved, "#")
+			if pm.isInstalled(filepath.Join(pm.nodeModulesPath, name), name, version) {
+				return nil
+			}
+		}
+	}
+
+	installedName, err := installGitPackage(pm, lockFile, spec, lockedCommit, isDev, false)
+	if err != nil {
+		return err
+	}
+	if installedName != name {
+		reportWarning("%s resolved to package %s, not %s", spec, installedName, name)
+	}
+	return nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func splitGitSpecs(specs []string) ([]string, []string) {
+	var registrySpecs, gitSpecs []string
+	for _, spec := range specs {
+		if isGitSpec(spec) {
+			gitSpecs = append(gitSpecs, spec)
+		} else {
+			registrySpecs = append(registrySpecs, spec)
+		}
+	}
+	return registrySpecs, gitSpecs
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+type GraphEdge struct {
+	From     string `json:"-"`
+	To       string `json:"to"`
+	Range    string `json:"range"`
+	Dev      bool   `json:"dev,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+type DependencyGraph struct {
+	Root  string                 `json:"root"`
+	Nodes map[string][]GraphEdge `json:"nodes"`
+}
+
+func buildDependencyGraph(pkg *PackageJSON, lockFile *LockFile) *DependencyGraph {
+	lockFile.mu.RLock()
+	defer lockFile.mu.RUnlock()
+
+	root := pkg.Name
+	if root == "" {
+		root = "(root)"
+	}
+	if pkg.Version != "" {
+		root = fmt.Sprintf("%s@%s", root, pkg.Version)
+	}
+
+	graph := &DependencyGraph{Root: root, Nodes: map[string][]GraphEdge{root: {}}}
+
+	byName := make(map[string][]LockPackage)
+	for _, lockPkg := range lockFile.Packages {
+		if lockPkg.Skipped {
+			continue
+		}
+		byName[lockPkg.Name] = append(byName[lockPkg.Name], lockPkg)
+		graph.Nodes[fmt.Sprintf("%s@%s", lockPkg.Name, lockPkg.Version)] = []GraphEdge{}
+	}
+
+	link := func(from string, deps map[string]string, dev, optional bool) {
+		for _, depName := range sortedKeys(deps) {
+			depRange := deps[depName]
+
+			var targets []LockPackage
+			for _, candidate := range byName[depName] {
+				if satisfiesRange(candidate.Version, depRange) {
+					targets = append(targets, candidate)
+				}
+			}
+			if len(targets) == 0 {
+				targets = byName[depName]
+			}
+
+			for _, target := range targets {
+				graph.Nodes[from] = append(graph.Nodes[from], GraphEdge{
+					From:     from,
+					To:       fmt.Sprintf("%s@%s", target.Name, target.Version),
+					Range:    depRange,
+					Dev:      dev,
+					Optional: optional,
+				})
+			}
+		}
+	}
+
+	link(root, pkg.Dependencies, false, false)
+	link(root, pkg.DevDependencies, true, false)
+	link(root, pkg.OptionalDependencies, false, true)
+	for _, lockPkg := range lockFile.Packages {
+		if !lockPkg.Skipped {
+			link(fmt.Sprintf("%s@%s", lockPkg.Name, lockPkg.Version), lockPkg.Dependencies, false, false)
+		}
+	}
+
+	for node, edges := range graph.Nodes {
+		sort.Slice(edges, func(i, j int) bool {
+			return edges[i].To < edges[j].To
+		})
+		graph.Nodes[node] = edges
+	}
+	return graph
+}
+
+func (g *DependencyGraph) sortedNodes() []string {
+	nodes := make([]string, 0, len(g.Nodes))
+	for node := range g.Nodes {
+		if node != g.Root {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+	return append([]string{g.Root}, nodes...)
+}
+
+func writeGraphDOT(w io.Writer, graph *DependencyGraph) {
+	fmt.Fprintln(w, "digraph dependencies {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box];")
+	fmt.Fprintf(w, "  %s [style=bold];\n", strconv.Quote(graph.Root))
+
+	nodes := graph.sortedNodes()
+	for _, node := range nodes[1:] {
+		fmt.Fprintf(w, "  %s;\n", strconv.Quote(node))
+	}
+	for _, node := range nodes {
+		for _, edge := range graph.Nodes[node] {
+			attrs := "label=" + strconv.Quote(edge.Range)
+			if edge.Dev {
+				attrs += ", style=dashed"
+			} else if edge.Optional {
+				attrs += ", style=dotted"
+			}
+			fmt.Fprintf(w, "  %s -> %s [%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To), attrs)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func printGraphJSON(graph *DependencyGraph) error {
+	data, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dependency graph: %v", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
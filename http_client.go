@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const auditTimeout = 30 * time.Second
+
+var sharedHTTPClient = &http.Client{Transport: newRegistryTransport()}
+
+func newRegistryTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = maxConcurrency
+	transport.IdleConnTimeout = 90 * time.Second
+	return transport
+}
+
+func requestContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
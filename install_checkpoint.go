@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+type installCheckpoint struct {
+	ManifestHash string
+	Completed    map[string]string
+	Packages     map[string]LockPackage
+}
+
+type checkpointHeader struct {
+	ManifestHash string `json:"manifestHash"`
+}
+
+type checkpointRecord struct {
+	Name     string                 `json:"name"`
+	Version  string                 `json:"version"`
+	Packages map[string]LockPackage `json:"packages,omitempty"`
+}
+
+type checkpointWriter struct {
+	file     *os.File
+	lockFile *LockFile
+	written  map[string]bool
+}
+
+func installCheckpointPath() string {
+	return filepath.Join(nodeModulesDir(), ".gpm", "checkpoint.jsonl")
+}
+
+func loadInstallCheckpoint(manifestPath string) *installCheckpoint {
+	file, err := os.Open(installCheckpointPath())
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		return nil
+	}
+
+	var header checkpointHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return nil
+	}
+	manifestHash, err := fileHash(manifestPath)
+	if err != nil || manifestHash != header.ManifestHash {
+		return nil
+	}
+
+	checkpoint := &installCheckpoint{
+		ManifestHash: header.ManifestHash,
+		Completed:    make(map[string]string),
+		Packages:     make(map[string]LockPackage),
+	}
+	for scanner.Scan() {
+		var record checkpointRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			break
+		}
+		checkpoint.Completed[record.Name] = record.Version
+		for key, lockPkg := range record.Packages {
+			checkpoint.Packages[key] = lockPkg
+		}
+	}
+	return checkpoint
+}
+
+func newCheckpointWriter(manifestPath string, lockFile *LockFile, resumed *installCheckpoint) (*checkpointWriter, error) {
+	manifestHash, err := fileHash(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	path := installCheckpointPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	writer := &checkpointWriter{lockFile: lockFile, written: make(map[string]bool)}
+	if resumed != nil {
+		writer.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+		for key := range resumed.Packages {
+			writer.written[key] = true
+		}
+	} else {
+		writer.file, err = os.Create(path)
+		if err == nil {
+			err = writer.writeLine(checkpointHeader{ManifestHash: manifestHash})
+		}
+	}
+	if err != nil {
+		if writer.file != nil {
+			writer.file.Close()
+		}
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (w *checkpointWriter) writeLine(value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = w.file.Write(append(data, '\n'))
+	return err
+}
+
+func (w *checkpointWriter) record(name, version string) {
+	record := checkpointRecord{Name: name, Version: version, Packages: make(map[string]LockPackage)}
+
+	w.lockFile.mu.RLock()
+	for key, lockPkg := range w.lockFile.Packages {
+		if !w.written[key] {
+			record.Packages[key] = lockPkg
+			w.written[key] = true
+		}
+	}
+	w.lockFile.mu.RUnlock()
+
+	if err := w.writeLine(record); err != nil {
+		reportWarning("Failed to write install checkpoint: %v", err)
+	}
+}
+
+func (w *checkpointWriter) close() {
+	w.file.Close()
+}
+
+func (lf *LockFile) restoreCheckpointPackages(packages map[string]LockPackage) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	for key, lockPkg := range packages {
+		if _, ok := lf.Packages[key]; !ok {
+			lf.Packages[key] = lockPkg
+		}
+	}
+}
+
+func clearInstallCheckpoint() {
+	os.Remove(installCheckpointPath())
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type installState struct {
+	LockfileHash string   `json:"lockfileHash"`
+	ManifestHash string   `json:"manifestHash"`
+	Options      []string `json:"options,omitempty"`
+}
+
+func installStatePath() string {
+	return filepath.Join(nodeModulesDir(), ".gpm", "state.json")
+}
+
+func installStateEnabled(pm *PackageManager) bool {
+	return config.getBool("install-state", true) && !lockFileDisabled && !pm.checkFiles && !pm.onlyMissing
+}
+
+func currentInstallState(pm *PackageManager, manifestPath string) (*installState, error) {
+	lockfileHash, err := fileHash(lockFileName())
+	if err != nil {
+		return nil, err
+	}
+	manifestHash, err := fileHash(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	options := []string{"manifest=" + manifestPath, "registry-host=" + pm.replaceHost}
+	if pm.ignoreEngines {
+		options = append(options, "ignore-engines")
+	}
+	if pm.engineStrict {
+		options = append(options, "engine-strict")
+	}
+	if pm.strictRanges {
+		options = append(options, "strict-ranges")
+	}
+	if pm.installPeers {
+		options = append(options, "install-peers")
+	}
+	if !pm.before.IsZero() {
+		options = append(options, "before="+pm.before.Format(time.RFC3339))
+	}
+	sort.Strings(options)
+
+	return &installState{LockfileHash: lockfileHash, ManifestHash: manifestHash, Options: options}, nil
+}
+
+func fileHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func installUpToDate(pm *PackageManager, manifestPath string, pkg *PackageJSON) bool {
+	if pm.force || !installStateEnabled(pm) {
+		return false
+	}
+
+	data, err := os.ReadFile(installStatePath())
+	if err != nil {
+		return false
+	}
+	var saved installState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return false
+	}
+
+	current, err := currentInstallState(pm, manifestPath)
+	if err != nil || current.LockfileHash != saved.LockfileHash || current.ManifestHash != saved.ManifestHash {
+		return false
+	}
+	if len(current.Options) != len(saved.Options) {
+		return false
+	}
+	for i := range current.Options {
+		if current.Options[i] != saved.Options[i] {
+			return false
+		}
+	}
+
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies} {
+		for name := range deps {
+			if !fileExists(filepath.Join(nodeModulesDir(), name, "package.json")) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func writeInstallState(pm *PackageManager, manifestPath string) error {
+	if !installStateEnabled(pm) {
+		return nil
+	}
+
+	state, err := currentInstallState(pm, manifestPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(installStatePath()), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(installStatePath(), data, 0644)
+}
+
+func clearInstallState() {
+	os.Remove(installStatePath())
+}
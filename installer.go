@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 )
@@ -36,7 +38,7 @@ func installPackage(pm *PackageManager, packageSpec string, isDev bool, writeToP
 
 	existingVersion := lockFile.getPackageVersion(name)
 	if existingVersion != "" && isPackageInstalled(filepath.Join("node_modules", name), existingVersion) {
-		fmt.Printf(" %s %s@%s %s\n", color.HiGreenString("✓"), color.CyanString(name), color.HiBlackString(existingVersion), color.HiBlackString("(cached)"))
+		output.Printf(" %s %s@%s %s\n", color.HiGreenString("✓"), color.CyanString(name), color.HiBlackString(existingVersion), color.HiBlackString("(cached)"))
 		return nil
 	}
 
@@ -53,16 +55,18 @@ func installPackage(pm *PackageManager, packageSpec string, isDev bool, writeToP
 	if err != nil {
 		return err
 	}
+	if installedVersion, err = validateVersion(installedVersion); err != nil {
+		return err
+	}
 
 	if wasCached {
-		fmt.Printf(" %s %s@%s %s\n", color.HiGreenString("✓"), color.CyanString(name), color.HiBlackString(installedVersion), color.HiBlackString("(from cache)"))
+		output.Printf(" %s %s@%s %s\n", color.HiGreenString("✓"), color.CyanString(name), color.HiBlackString(installedVersion), color.HiBlackString("(from cache)"))
 		return nil
 	}
 
 	if installDeps {
 		if err := pm.InstallDependencies(name, lockFile); err != nil {
-			fmt.Print("\r                                                    \r")
-			fmt.Printf(" %s Warning: Failed to install some dependencies for %s: %v\n", color.YellowString("⚠"), name, err)
+			output.Printf(" %s Warning: Failed to install some dependencies for %s: %v\n", color.YellowString("⚠"), name, err)
 		}
 	}
 
@@ -72,20 +76,17 @@ func installPackage(pm *PackageManager, packageSpec string, isDev bool, writeToP
 	}
 
 	if err := lockFile.addPackage(name, installedVersion, originalSpec, isDev); err != nil {
-		fmt.Print("\r                                                    \r")
-		fmt.Printf(" %s Failed to update lockfile: %v\n", color.YellowString("⚠"), err)
+		output.Printf(" %s Failed to update lockfile: %v\n", color.YellowString("⚠"), err)
 	}
 
 	if writeToPackageJSON {
-		if err := updatePackageJSON(name, installedVersion, isDev); err != nil {
-			fmt.Print("\r                                                    \r")
-			fmt.Printf(" %s Failed to update package.json: %v\n", color.YellowString("⚠"), err)
+		if err := updatePackageJSON(name, savePrefix()+installedVersion, isDev); err != nil {
+			output.Printf(" %s Failed to update package.json: %v\n", color.YellowString("⚠"), err)
 			return nil
 		}
 	}
 
-	fmt.Print("\r                                                    \r")
-	fmt.Printf(" %s %s@%s %s\n",
+	output.Printf(" %s %s@%s %s\n",
 		color.HiGreenString("✓"),
 		color.CyanString(name),
 		color.HiBlackString(installedVersion),
@@ -93,98 +94,165 @@ func installPackage(pm *PackageManager, packageSpec string, isDev bool, writeToP
 
 	bm := NewBinaryManager()
 	if err := bm.setupPackageBinaries(name); err != nil {
-		fmt.Printf(" %s Failed to setup binaries for %s: %v\n", color.YellowString("⚠"), name, err)
+		output.Printf(" %s Failed to setup binaries for %s: %v\n", color.YellowString("⚠"), name, err)
 	}
 
 	return nil
 }
 
-func installFromPackageJSON(pm *PackageManager, lockFile *LockFile) error {
-	timer := NewTimer()
-	timer.Start()
-	data, err := os.ReadFile("package.json")
+func installFromPackageJSON(pm *PackageManager, lockFile *LockFile, manifestPath string) error {
+	startTime := time.Now()
+	data, err := os.ReadFile(manifestPath)
 	if err != nil {
-		return fmt.Errorf("failed to read package.json: %v", err)
+		return fmt.Errorf("failed to read %s: %v", manifestPath, err)
 	}
 
 	var pkg PackageJSON
-	if err := json.Unmarshal(data, &pkg); err != nil {
-		return fmt.Errorf("failed to parse package.json: %v", err)
+	if err := parseManifest(data, &pkg); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", manifestPath, err)
 	}
 
-	totalPackages := len(pkg.Dependencies) + len(pkg.DevDependencies)
-	if totalPackages == 0 {
-		fmt.Println("No dependencies found in package.json")
+	warnings, err := checkManifestDependencies(&pkg, data)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %v", manifestPath, err)
+	}
+	for _, warning := range warnings {
+		reportWarning("%s: %s", manifestPath, warning)
+	}
+
+	// The project's preinstall and postinstall scripts still run around an
+	// up-to-date install, as they do with npm: they often generate files the
+	// project needs and do not depend on whether node_modules changed.
+	if installUpToDate(pm, manifestPath, &pkg) {
+		if reporter.Human() {
+			output.Printf(" %s Already up to date\n", color.HiGreenString("✓"))
+		}
+		reporter.Report(InstallEvent{Type: "done", ElapsedMs: time.Since(startTime).Milliseconds()})
 		return nil
 	}
 
-	var jobs []PackageJob
+	timer := NewTimer()
+	timer.Start()
 
-	for name, version := range pkg.Dependencies {
-		packageSpec := name
-		if version != "" && version != "latest" {
-			cleanVersion := strings.TrimPrefix(strings.TrimPrefix(version, "^"), "~")
-			if cleanVersion != version && cleanVersion != "" {
-				packageSpec = name + "@" + cleanVersion
-			}
-		}
+	lockFile.manifestPath = manifestPath
 
-		parsedName, parsedVersion := parsePackageSpec(packageSpec)
-		originalSpec := packageSpec
-		if parsedVersion == "latest" {
-			originalSpec = parsedName
+	totalPackages := len(pkg.Dependencies) + len(pkg.DevDependencies) + len(pkg.OptionalDependencies)
+	if totalPackages == 0 {
+		if reporter.Human() {
+			fmt.Printf("No dependencies found in %s\n", manifestPath)
 		}
-
-		jobs = append(jobs, PackageJob{
-			Name:         parsedName,
-			Version:      parsedVersion,
-			IsDev:        false,
-			OriginalSpec: originalSpec,
-		})
+		return nil
 	}
 
-	for name, version := range pkg.DevDependencies {
-		packageSpec := name
-		if version != "" && version != "latest" {
-			cleanVersion := strings.TrimPrefix(strings.TrimPrefix(version, "^"), "~")
-			if cleanVersion != version && cleanVersion != "" {
-				packageSpec = name + "@" + cleanVersion
+	if pm.strictRanges {
+		var unpinned []string
+		for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.OptionalDependencies} {
+			for name, version := range deps {
+				if strings.TrimSpace(version) == "" {
+					unpinned = append(unpinned, name)
+				}
 			}
 		}
+		if len(unpinned) > 0 {
+			sort.Strings(unpinned)
+			return unpinnedError(unpinned)
+		}
+	}
 
-		parsedName, parsedVersion := parsePackageSpec(packageSpec)
-		originalSpec := packageSpec
-		if parsedVersion == "latest" {
-			originalSpec = parsedName
+	clearInstallState()
+
+	jobs, gitDeps := manifestJobs(&pkg)
+
+	parallelInstaller := NewParallelInstaller(pm, lockFile, timer)
+	if installStateEnabled(pm) && config.getBool("install-checkpoint", true) {
+		var resumed *installCheckpoint
+		if !pm.force {
+			resumed = loadInstallCheckpoint(manifestPath)
+		}
+		if resumed != nil {
+			lockFile.restoreCheckpointPackages(resumed.Packages)
+			pm.resumed = resumed.Completed
+			if reporter.Human() {
+				fmt.Printf(" %s Resuming install: %d packages already done\n", color.CyanString("↻"), len(resumed.Completed))
+			}
 		}
 
-		jobs = append(jobs, PackageJob{
-			Name:         parsedName,
-			Version:      parsedVersion,
-			IsDev:        true,
-			OriginalSpec: originalSpec,
-		})
+		checkpoint, err := newCheckpointWriter(manifestPath, lockFile, resumed)
+		if err != nil {
+			reportWarning("Failed to start install checkpoint: %v", err)
+		}
+		parallelInstaller.checkpoint = checkpoint
+	}
+	installErr := parallelInstaller.InstallPackages(jobs, false)
+	if _, partial := installErr.(*partialInstallError); installErr != nil && !partial {
+		return installErr
 	}
 
-	parallelInstaller := NewParallelInstaller(pm, lockFile, timer)
-	if err := parallelInstaller.InstallPackages(jobs, false); err != nil {
-		return err
+	for _, dep := range gitDeps {
+		if err := installGitDependency(pm, lockFile, dep.Name, dep.Spec, dep.IsDev); err != nil {
+			if dep.Optional {
+				reportWarning("Skipping optional dependency %s from %s: %v", dep.Name, dep.Spec, err)
+				continue
+			}
+			return fmt.Errorf("failed to install %s from %s: %v", dep.Name, dep.Spec, err)
+		}
 	}
+	pm.reportDependencyFailures()
 
 	if err := lockFile.saveLockFile(); err != nil {
-		fmt.Printf(" %s Failed to save lockfile: %v\n", color.YellowString("⚠"), err)
+		reportWarning("Failed to save lockfile: %v", err)
 	}
 
 	bm := NewBinaryManager()
 	if err := bm.setupAllBinaries(); err != nil {
-		fmt.Printf(" %s Failed to setup some binaries: %v\n", color.YellowString("⚠"), err)
+		reportWarning("Failed to setup some binaries: %v", err)
+	}
+
+	if installErr == nil && !pm.hasDependencyFailures() {
+		if err := writeInstallState(pm, manifestPath); err != nil {
+			reportWarning("Failed to record install state: %v", err)
+		}
+		clearInstallCheckpoint()
 	}
 
 	elapsed := timer.Stop()
-	fmt.Printf("\n %s Done in %s\n",
-		color.HiGreenString("✓"),
-		color.HiBlackString(formatDuration(elapsed)))
-	return nil
+	reporter.Report(InstallEvent{Type: "done", ElapsedMs: elapsed.Milliseconds()})
+	return installErr
+}
+
+func manifestJobs(pkg *PackageJSON) ([]PackageJob, []gitDependency) {
+	var jobs []PackageJob
+	var gitDeps []gitDependency
+
+	addSection := func(deps map[string]string, isDev, optional bool) {
+		for name, version := range deps {
+			if isGitSpec(version) {
+				gitDeps = append(gitDeps, gitDependency{Name: name, Spec: version, IsDev: isDev, Optional: optional})
+				continue
+			}
+
+			var job PackageJob
+			if strings.HasPrefix(version, "npm:") {
+				job = newPackageJob(name, version, isDev, name+"@"+version)
+			} else {
+				parsedVersion := registryVersion(version)
+				originalSpec := name + "@" + version
+				if parsedVersion == "latest" {
+					originalSpec = name
+				}
+				job = newPackageJob(name, parsedVersion, isDev, originalSpec)
+			}
+			job.Optional = optional
+			jobs = append(jobs, job)
+		}
+	}
+
+	addSection(pkg.Dependencies, false, false)
+	addSection(pkg.DevDependencies, true, false)
+	addSection(pkg.OptionalDependencies, false, true)
+
+	sort.Slice(gitDeps, func(i, j int) bool { return gitDeps[i].Name < gitDeps[j].Name })
+	return jobs, gitDeps
 }
 
 func isPackageInstalled(packagePath, version string) bool {
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestManifestJobs(t *testing.T) {
+	data := []byte(`{
+  "name": "my-app",
+  "dependencies": {"lodash": "^4.17.21", "old-lodash": "npm:lodash@^3.10.0", "tool": "github:user/tool"},
+  "devDependencies": {"jest": "=29.0.0", "types": "*"},
+  "optionalDependencies": {"fsevents": "^2.3.0", "opt-ms": "npm:ms@2.1.3", "opt-tool": "git+https://example.com/opt-tool.git"}
+}`)
+	var pkg PackageJSON
+	if err := parseManifest(data, &pkg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkManifestDependencies(&pkg, data); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, gitDeps := manifestJobs(&pkg)
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+
+	wantJobs := []PackageJob{
+		{Name: "fsevents", Version: "^2.3.0", Optional: true, OriginalSpec: "fsevents@^2.3.0"},
+		{Name: "jest", Version: "29.0.0", IsDev: true, OriginalSpec: "jest@=29.0.0"},
+		{Name: "lodash", Version: "^4.17.21", OriginalSpec: "lodash@^4.17.21"},
+		{Name: "old-lodash", Version: "^3.10.0", RegistryName: "lodash", OriginalSpec: "old-lodash@npm:lodash@^3.10.0"},
+		{Name: "opt-ms", Version: "2.1.3", RegistryName: "ms", Optional: true, OriginalSpec: "opt-ms@npm:ms@2.1.3"},
+		{Name: "types", Version: "latest", IsDev: true, OriginalSpec: "types"},
+	}
+	if !reflect.DeepEqual(jobs, wantJobs) {
+		t.Errorf("jobs =\n%+v\nwant\n%+v", jobs, wantJobs)
+	}
+
+	wantGitDeps := []gitDependency{
+		{Name: "opt-tool", Spec: "git+https://example.com/opt-tool.git", Optional: true},
+		{Name: "tool", Spec: "github:user/tool"},
+	}
+	if !reflect.DeepEqual(gitDeps, wantGitDeps) {
+		t.Errorf("gitDeps = %+v, want %+v", gitDeps, wantGitDeps)
+	}
+}
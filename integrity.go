@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+type integrityHasher struct {
+	sha1   hash.Hash
+	sha512 hash.Hash
+}
+
+func newIntegrityHasher() *integrityHasher {
+	return &integrityHasher{sha1: sha1.New(), sha512: sha512.New()}
+}
+
+func (h *integrityHasher) Write(p []byte) (int, error) {
+	h.sha1.Write(p)
+	h.sha512.Write(p)
+	return len(p), nil
+}
+
+func (h *integrityHasher) Integrity() string {
+	return "sha512-" + base64.StdEncoding.EncodeToString(h.sha512.Sum(nil)) +
+		" sha1-" + base64.StdEncoding.EncodeToString(h.sha1.Sum(nil))
+}
+
+func parseIntegrity(integrity string) map[string][]string {
+	digests := make(map[string][]string)
+	for _, token := range strings.Fields(integrity) {
+		algorithm, digest, ok := strings.Cut(token, "-")
+		if !ok {
+			continue
+		}
+		digest, _, _ = strings.Cut(digest, "?")
+		digests[algorithm] = append(digests[algorithm], digest)
+	}
+	return digests
+}
+
+func sharesIntegrityAlgorithm(expected, actual string) bool {
+	actualDigests := parseIntegrity(actual)
+	for algorithm := range parseIntegrity(expected) {
+		if _, ok := actualDigests[algorithm]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func integrityMatches(expected, actual string) bool {
+	actualDigests := parseIntegrity(actual)
+	matched := false
+	for algorithm, digests := range parseIntegrity(expected) {
+		candidates, ok := actualDigests[algorithm]
+		if !ok {
+			continue
+		}
+		found := false
+		for _, digest := range digests {
+			for _, candidate := range candidates {
+				if digest == candidate {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+func strongestIntegrity(integrity string) string {
+	digests := parseIntegrity(integrity)
+	for _, algorithm := range []string{"sha512", "sha384", "sha256", "sha1"} {
+		if values, ok := digests[algorithm]; ok {
+			return algorithm + "-" + values[0]
+		}
+	}
+	return ""
+}
+
+func distIntegrity(dist DistInfo) string {
+	if integrity := strongestIntegrity(dist.Integrity); integrity != "" {
+		return integrity
+	}
+	return shasumToIntegrity(dist.Shasum)
+}
+
+func verifyDistIntegrity(pkgInfo *PackageInfo, actual string) error {
+	if pkgInfo.Dist.Integrity != "" && sharesIntegrityAlgorithm(pkgInfo.Dist.Integrity, actual) {
+		if !integrityMatches(pkgInfo.Dist.Integrity, actual) {
+			return fmt.Errorf("integrity check failed for %s@%s: expected %s, got %s",
+				pkgInfo.Name, pkgInfo.Version, strongestIntegrity(pkgInfo.Dist.Integrity), matchingDigest(pkgInfo.Dist.Integrity, actual))
+		}
+		return nil
+	}
+
+	if expected := shasumToIntegrity(pkgInfo.Dist.Shasum); expected != "" && !integrityMatches(expected, actual) {
+		return fmt.Errorf("integrity check failed for %s@%s: expected %s, got %s",
+			pkgInfo.Name, pkgInfo.Version, expected, matchingDigest(expected, actual))
+	}
+	return nil
+}
+
+func matchingDigest(expected, actual string) string {
+	algorithm, _, _ := strings.Cut(strongestIntegrity(expected), "-")
+	if digests := parseIntegrity(actual)[algorithm]; len(digests) > 0 {
+		return algorithm + "-" + digests[0]
+	}
+	return actual
+}
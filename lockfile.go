@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,38 +21,74 @@ type LockFile struct {
 	Specifiers  map[string]string      `yaml:"specifiers"`
 	DevPackages map[string]string      `yaml:"devPackages,omitempty"`
 	mu          sync.RWMutex           `yaml:"-"`
+
+	manifestPath string
+	readOnly     bool
 }
 
 type LockPackage struct {
 	Name         string            `yaml:"name"`
 	Version      string            `yaml:"version"`
-	Resolved     string            `yaml:"resolved"`
+	Resolved     string            `yaml:"resolved,omitempty"`
 	Integrity    string            `yaml:"integrity,omitempty"`
 	Dependencies map[string]string `yaml:"dependencies,omitempty"`
 	DevDep       bool              `yaml:"dev,omitempty"`
+	Direct       bool              `yaml:"direct,omitempty"`
+	Optional     bool              `yaml:"optional,omitempty"`
+	Peer         bool              `yaml:"peer,omitempty"`
+	Skipped      bool              `yaml:"skipped,omitempty"`
 }
 
-const lockFileName = "gpm-lock.yaml"
+const (
+	defaultLockFileName    = "gpm-lock.yaml"
+	currentLockFileVersion = "1.1"
+)
+
+var lockFileMigrations = map[string]func(*LockFile) string{
+	"1.0": migrateLockFileV1_0,
+}
+
+func lockFileName() string {
+	name := config.getDefault("lockfile", defaultLockFileName)
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(installPrefix, name)
+}
+
+var lockFileDisabled = false
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+func newLockFile() *LockFile {
+	return &LockFile{
+		Version:     currentLockFileVersion,
+		CreatedAt:   time.Now(),
+		Packages:    make(map[string]LockPackage),
+		Specifiers:  make(map[string]string),
+		DevPackages: make(map[string]string),
+	}
+}
 
 func loadLockFile() (*LockFile, error) {
-	if !fileExists(lockFileName) {
-		return &LockFile{
-			Version:     "1.0",
-			CreatedAt:   time.Now(),
-			Packages:    make(map[string]LockPackage),
-			Specifiers:  make(map[string]string),
-			DevPackages: make(map[string]string),
-		}, nil
+	path := lockFileName()
+
+	if lockFileDisabled || !fileExists(path) {
+		return newLockFile(), nil
 	}
 
-	data, err := os.ReadFile(lockFileName)
+	return readLockFile(path)
+}
+
+func readLockFile(path string) (*LockFile, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read lockfile: %v", err)
 	}
 
 	var lockFile LockFile
 	if err := yaml.Unmarshal(data, &lockFile); err != nil {
-		return nil, fmt.Errorf("failed to parse lockfile: %v", err)
+		return nil, describeLockFileError(path, data, err)
 	}
 
 	if lockFile.Packages == nil {
@@ -62,10 +101,115 @@ func loadLockFile() (*LockFile, error) {
 		lockFile.DevPackages = make(map[string]string)
 	}
 
+	if err := lockFile.migrate(); err != nil {
+		return nil, err
+	}
+
 	return &lockFile, nil
 }
 
+func describeLockFileError(path string, data []byte, err error) error {
+	message := fmt.Sprintf("%s is corrupt: %v", path, err)
+
+	if match := yamlLinePattern.FindStringSubmatch(err.Error()); match != nil {
+		lineNumber, _ := strconv.Atoi(match[1])
+		lines := strings.Split(string(data), "\n")
+		if lineNumber >= 1 && lineNumber <= len(lines) {
+			message += fmt.Sprintf("\n  %d | %s", lineNumber, lines[lineNumber-1])
+		}
+	}
+
+	message += "\nRun 'gpm install --fix-lockfile' to regenerate it, or pass --no-lockfile to ignore it"
+	return fmt.Errorf("%s", message)
+}
+
+func regenerateLockFile(nodeModulesPath string, cache *Cache) (*LockFile, error) {
+	pkg, err := loadPackageJSON("package.json")
+	if err != nil {
+		return nil, err
+	}
+
+	lockFile := newLockFile()
+
+	packages, err := listInstalledPackages(nodeModulesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range packages {
+		manifest, err := readInstalledManifest(filepath.Join(nodeModulesPath, name))
+		if err != nil || manifest.Version == "" {
+			continue
+		}
+
+		_, isDev := pkg.DevDependencies[name]
+
+		lockFile.addPackage(name, manifest.Version, name, isDev)
+		lockFile.setResolution(name, manifest.Version, "", strongestIntegrity(cache.getIntegrity(name, manifest.Version)))
+	}
+
+	return lockFile, nil
+}
+
+func (lf *LockFile) migrate() error {
+	if lf.Version == "" {
+		lf.Version = "1.0"
+	}
+
+	for lf.Version != currentLockFileVersion {
+		if compareVersions(lf.Version, currentLockFileVersion) > 0 {
+			return fmt.Errorf("%s has lockfileVersion %s, but this gpm only supports up to %s; please upgrade gpm", lockFileName(), lf.Version, currentLockFileVersion)
+		}
+
+		migration, ok := lockFileMigrations[lf.Version]
+		if !ok {
+			return fmt.Errorf("unsupported lockfileVersion %s in %s", lf.Version, lockFileName())
+		}
+		lf.Version = migration(lf)
+	}
+
+	return nil
+}
+
+func migrateLockFileV1_0(lf *LockFile) string {
+	lf.markDirectPackages()
+	return "1.1"
+}
+
+func (lf *LockFile) markDirectPackages() {
+	manifestPath := lf.manifestPath
+	if manifestPath == "" {
+		manifestPath = "package.json"
+	}
+
+	pkg, err := loadPackageJSON(manifestPath)
+	if err != nil {
+		return
+	}
+
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	for key, lockPkg := range lf.Packages {
+		_, isDep := pkg.Dependencies[lockPkg.Name]
+		_, isDevDep := pkg.DevDependencies[lockPkg.Name]
+		_, isOptionalDep := pkg.OptionalDependencies[lockPkg.Name]
+		lockPkg.Direct = isDep || isDevDep || isOptionalDep
+		lf.Packages[key] = lockPkg
+	}
+}
+
 func (lf *LockFile) saveLockFile() error {
+	if lockFileDisabled || lf.readOnly {
+		return nil
+	}
+
+	lf.markDirectPackages()
+
+	return lf.writeLockFile(lockFileName())
+}
+
+func (lf *LockFile) writeLockFile(path string) error {
 	lf.mu.RLock()
 	defer lf.mu.RUnlock()
 	
@@ -76,13 +220,52 @@ func (lf *LockFile) saveLockFile() error {
 		return fmt.Errorf("failed to marshal lockfile: %v", err)
 	}
 
-	if err := os.WriteFile(lockFileName, data, 0644); err != nil {
+	if err := writeFileAtomic(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write lockfile: %v", err)
 	}
 
 	return nil
 }
 
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpFile.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
 func (lf *LockFile) addPackage(name, version, specifier string, isDev bool) error {
 	packageKey := fmt.Sprintf("%s@%s", name, version)
 
@@ -101,7 +284,19 @@ func (lf *LockFile) addPackage(name, version, specifier string, isDev bool) erro
 
 	lf.mu.Lock()
 	defer lf.mu.Unlock()
-	
+
+	if existing, ok := lf.Packages[packageKey]; ok && !existing.Skipped {
+		lockPkg.Resolved = existing.Resolved
+		lockPkg.Integrity = existing.Integrity
+		lockPkg.Optional = existing.Optional
+		lockPkg.Peer = existing.Peer
+	}
+	for key, existing := range lf.Packages {
+		if existing.Name == name && existing.Skipped {
+			lockPkg.Optional = true
+			delete(lf.Packages, key)
+		}
+	}
 	lf.Packages[packageKey] = lockPkg
 	lf.Specifiers[name] = specifier
 
@@ -112,6 +307,89 @@ func (lf *LockFile) addPackage(name, version, specifier string, isDev bool) erro
 	return nil
 }
 
+func (lf *LockFile) setResolution(name, version, resolved, integrity string) {
+	packageKey := fmt.Sprintf("%s@%s", name, version)
+
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	lockPkg, ok := lf.Packages[packageKey]
+	if !ok {
+		return
+	}
+	if resolved != "" {
+		lockPkg.Resolved = resolved
+	}
+	if integrity != "" {
+		lockPkg.Integrity = integrity
+	}
+	lf.Packages[packageKey] = lockPkg
+}
+
+func (lf *LockFile) addSkippedOptional(name, version string, isDev bool) {
+	packageKey := fmt.Sprintf("%s@%s", name, version)
+
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if existing, ok := lf.Packages[packageKey]; ok && !existing.Skipped {
+		return
+	}
+
+	lf.Packages[packageKey] = LockPackage{
+		Name:     name,
+		Version:  version,
+		DevDep:   isDev,
+		Optional: true,
+		Skipped:  true,
+	}
+}
+
+func (lf *LockFile) markOptional(name, version string) {
+	packageKey := fmt.Sprintf("%s@%s", name, version)
+
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lockPkg, ok := lf.Packages[packageKey]; ok {
+		lockPkg.Optional = true
+		lf.Packages[packageKey] = lockPkg
+	}
+}
+
+func (lf *LockFile) markPeer(name, version string) {
+	packageKey := fmt.Sprintf("%s@%s", name, version)
+
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	if lockPkg, ok := lf.Packages[packageKey]; ok {
+		lockPkg.Peer = true
+		lf.Packages[packageKey] = lockPkg
+	}
+}
+
+func (lf *LockFile) isSkippedOptional(name string) bool {
+	lf.mu.RLock()
+	defer lf.mu.RUnlock()
+
+	for _, pkg := range lf.Packages {
+		if pkg.Name == name && pkg.Skipped {
+			return true
+		}
+	}
+	return false
+}
+
+func (lf *LockFile) getIntegrity(name, version string) string {
+	packageKey := fmt.Sprintf("%s@%s", name, version)
+
+	lf.mu.RLock()
+	defer lf.mu.RUnlock()
+
+	return lf.Packages[packageKey].Integrity
+}
+
 func (lf *LockFile) hasPackage(name, version string) bool {
 	packageKey := fmt.Sprintf("%s@%s", name, version)
 	
@@ -134,8 +412,20 @@ func (lf *LockFile) getPackageVersion(name string) string {
 	return ""
 }
 
+func (lf *LockFile) getLockedPackage(name string) (LockPackage, bool) {
+	lf.mu.RLock()
+	defer lf.mu.RUnlock()
+
+	for _, pkg := range lf.Packages {
+		if pkg.Name == name {
+			return pkg, true
+		}
+	}
+	return LockPackage{}, false
+}
+
 func getPackageDependencies(packageName string) (map[string]string, error) {
-	packagePath := filepath.Join("node_modules", packageName, "package.json")
+	packagePath := filepath.Join(nodeModulesDir(), packageName, "package.json")
 
 	if !fileExists(packagePath) {
 		return make(map[string]string), nil
@@ -161,6 +451,31 @@ func getPackageDependencies(packageName string) (map[string]string, error) {
 	return pkg.Dependencies, nil
 }
 
+func (lf *LockFile) setDevDependency(name string, isDev bool) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	for key, pkg := range lf.Packages {
+		if pkg.Name == name {
+			pkg.DevDep = isDev
+			lf.Packages[key] = pkg
+		}
+	}
+
+	if isDev {
+		lf.DevPackages[name] = lf.Specifiers[name]
+	} else {
+		delete(lf.DevPackages, name)
+	}
+}
+
+func (lf *LockFile) removePackageVersion(name, version string) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	delete(lf.Packages, fmt.Sprintf("%s@%s", name, version))
+}
+
 func (lf *LockFile) removePackage(name string) {
 	lf.mu.Lock()
 	defer lf.mu.Unlock()
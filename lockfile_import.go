@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type importedPackage struct {
+	Name         string
+	Version      string
+	Resolved     string
+	Integrity    string
+	Dependencies map[string]string
+	Dev          bool
+	Optional     bool
+	Hoisted      bool
+	Ranges       []string
+}
+
+type foreignLockfile struct {
+	Tool  string
+	File  string
+	parse func(data []byte) ([]importedPackage, error)
+}
+
+var foreignLockfiles = []foreignLockfile{
+	{"npm", "npm-shrinkwrap.json", parseNpmLockfile},
+	{"npm", "package-lock.json", parseNpmLockfile},
+	{"yarn", "yarn.lock", parseYarnLockfile},
+	{"pnpm", "pnpm-lock.yaml", parsePnpmLockfile},
+}
+
+func (f foreignLockfile) path() string {
+	return filepath.Join(installPrefix, f.File)
+}
+
+func detectForeignLockfiles() []foreignLockfile {
+	var found []foreignLockfile
+	for _, lockfile := range foreignLockfiles {
+		if fileExists(lockfile.path()) {
+			found = append(found, lockfile)
+		}
+	}
+	return found
+}
+
+func (f foreignLockfile) read() ([]importedPackage, error) {
+	data, err := os.ReadFile(f.path())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", f.File, err)
+	}
+	packages, err := f.parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", f.File, err)
+	}
+	return packages, nil
+}
+
+type npmLockfile struct {
+	Packages     map[string]npmPackageEntry `json:"packages"`
+	Dependencies map[string]npmV1Entry      `json:"dependencies"`
+}
+
+type npmPackageEntry struct {
+	Version              string            `json:"version"`
+	Resolved             string            `json:"resolved"`
+	Integrity            string            `json:"integrity"`
+	Dev                  bool              `json:"dev"`
+	Optional             bool              `json:"optional"`
+	Link                 bool              `json:"link"`
+	Dependencies         map[string]string `json:"dependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+}
+
+type npmV1Entry struct {
+	Version      string                `json:"version"`
+	Resolved     string                `json:"resolved"`
+	Integrity    string                `json:"integrity"`
+	Dev          bool                  `json:"dev"`
+	Optional     bool                  `json:"optional"`
+	Requires     map[string]string     `json:"requires"`
+	Dependencies map[string]npmV1Entry `json:"dependencies"`
+}
+
+func parseNpmLockfile(data []byte) ([]importedPackage, error) {
+	var lockfile npmLockfile
+	if err := json.Unmarshal(data, &lockfile); err != nil {
+		return nil, err
+	}
+
+	var packages []importedPackage
+	if len(lockfile.Packages) > 0 {
+		for key, entry := range lockfile.Packages {
+			index := strings.LastIndex(key, "node_modules/")
+			if index < 0 || entry.Link || entry.Version == "" {
+				continue
+			}
+			name := key[index+len("node_modules/"):]
+
+			deps := make(map[string]string)
+			for _, source := range []map[string]string{entry.Dependencies, entry.OptionalDependencies} {
+				for depName, depRange := range source {
+					deps[depName] = depRange
+				}
+			}
+			packages = append(packages, importedPackage{
+				Name:         name,
+				Version:      entry.Version,
+				Resolved:     entry.Resolved,
+				Integrity:    entry.Integrity,
+				Dependencies: deps,
+				Dev:          entry.Dev,
+				Optional:     entry.Optional,
+				Hoisted:      key == "node_modules/"+name,
+			})
+		}
+		return packages, nil
+	}
+
+	var walk func(entries map[string]npmV1Entry, hoisted bool)
+	walk = func(entries map[string]npmV1Entry, hoisted bool) {
+		for name, entry := range entries {
+			if entry.Version == "" || strings.HasPrefix(entry.Version, "file:") {
+				continue
+			}
+			packages = append(packages, importedPackage{
+				Name:         name,
+				Version:      entry.Version,
+				Resolved:     entry.Resolved,
+				Integrity:    entry.Integrity,
+				Dependencies: entry.Requires,
+				Dev:          entry.Dev,
+				Optional:     entry.Optional,
+				Hoisted:      hoisted,
+			})
+			walk(entry.Dependencies, false)
+		}
+	}
+	walk(lockfile.Dependencies, true)
+	return packages, nil
+}
+
+func parseYarnLockfile(data []byte) ([]importedPackage, error) {
+	var packages []importedPackage
+	var current *importedPackage
+	inDependencies := false
+
+	flush := func() {
+		if current != nil && current.Version != "" {
+			packages = append(packages, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent == 0 {
+			flush()
+			inDependencies = false
+			header := strings.TrimSuffix(trimmed, ":")
+			spec := unquoteYarn(strings.TrimSpace(strings.Split(header, ",")[0]))
+			if header == "__metadata" || strings.Contains(spec, "@workspace:") || strings.Contains(spec, "@file:") || strings.Contains(spec, "@link:") {
+				continue
+			}
+			current = &importedPackage{Name: yarnSpecName(spec), Dependencies: make(map[string]string)}
+			for _, part := range strings.Split(header, ",") {
+				part = unquoteYarn(strings.TrimSpace(part))
+				current.Ranges = append(current.Ranges, strings.TrimPrefix(strings.TrimPrefix(part, current.Name+"@"), "npm:"))
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		key, value := splitYarnField(trimmed)
+		if indent > 2 {
+			if inDependencies {
+				current.Dependencies[key] = value
+			}
+			continue
+		}
+
+		inDependencies = false
+		switch key {
+		case "version":
+			current.Version = value
+		case "resolved":
+			current.Resolved = value
+		case "integrity":
+			current.Integrity = value
+		case "dependencies", "optionalDependencies":
+			inDependencies = true
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(packages) == 0 && len(bytes.TrimSpace(data)) > 0 && !bytes.Contains(data, []byte("__metadata")) {
+		return nil, fmt.Errorf("no packages found")
+	}
+	return packages, nil
+}
+
+func yarnSpecName(spec string) string {
+	index := strings.Index(spec[min(1, len(spec)):], "@")
+	if index < 0 {
+		return spec
+	}
+	return spec[:index+1]
+}
+
+func splitYarnField(line string) (string, string) {
+	if key, value, ok := strings.Cut(line, ": "); ok {
+		return unquoteYarn(key), unquoteYarn(strings.TrimSpace(value))
+	}
+	if strings.HasSuffix(line, ":") {
+		return unquoteYarn(strings.TrimSuffix(line, ":")), ""
+	}
+	key, value, _ := strings.Cut(line, " ")
+	return unquoteYarn(key), unquoteYarn(strings.TrimSpace(value))
+}
+
+func unquoteYarn(value string) string {
+	return strings.Trim(value, `"'`)
+}
+
+type pnpmLockfile struct {
+	Packages  map[string]pnpmPackageEntry `yaml:"packages"`
+	Snapshots map[string]struct {
+		Dependencies map[string]string `yaml:"dependencies"`
+	} `yaml:"snapshots"`
+}
+
+type pnpmPackageEntry struct {
+	Resolution struct {
+		Integrity string `yaml:"integrity"`
+		Tarball   string `yaml:"tarball"`
+	} `yaml:"resolution"`
+	Dependencies map[string]string `yaml:"dependencies"`
+	Dev          bool              `yaml:"dev"`
+	Optional     bool              `yaml:"optional"`
+}
+
+func parsePnpmLockfile(data []byte) ([]importedPackage, error) {
+	var lockfile pnpmLockfile
+	if err := yaml.Unmarshal(data, &lockfile); err != nil {
+		return nil, err
+	}
+
+	var packages []importedPackage
+	for key, entry := range lockfile.Packages {
+		name, version := pnpmPackageKey(key)
+		if name == "" || version == "" {
+			continue
+		}
+
+		deps := make(map[string]string)
+		sources := []map[string]string{entry.Dependencies, lockfile.Snapshots[key].Dependencies}
+		for _, source := range sources {
+			for depName, depVersion := range source {
+				deps[depName], _, _ = strings.Cut(depVersion, "(")
+			}
+		}
+		packages = append(packages, importedPackage{
+			Name:         name,
+			Version:      version,
+			Resolved:     entry.Resolution.Tarball,
+			Integrity:    entry.Resolution.Integrity,
+			Dependencies: deps,
+			Dev:          entry.Dev,
+			Optional:     entry.Optional,
+		})
+	}
+	return packages, nil
+}
+
+func pnpmPackageKey(key string) (string, string) {
+	key = strings.TrimPrefix(key, "/")
+	key, _, _ = strings.Cut(key, "(")
+
+	separator := strings.LastIndex(key, "@")
+	if separator <= 0 {
+		separator = strings.LastIndex(key, "/")
+	}
+	if separator <= 0 {
+		return "", ""
+	}
+
+	version, _, _ := strings.Cut(key[separator+1:], "_")
+	return key[:separator], version
+}
+
+func buildImportedLockFile(packages []importedPackage, pkg *PackageJSON) (*LockFile, map[string][]string) {
+	byName := make(map[string][]importedPackage)
+	for _, imported := range packages {
+		byName[imported.Name] = append(byName[imported.Name], imported)
+	}
+
+	rootRanges := make(map[string]string)
+	for _, deps := range []map[string]string{pkg.OptionalDependencies, pkg.DevDependencies, pkg.Dependencies} {
+		for name, depRange := range deps {
+			rootRanges[name] = depRange
+		}
+	}
+
+	lockFile := newLockFile()
+	original := make(map[string][]string)
+	for name, candidates := range byName {
+		sort.Slice(candidates, func(i, j int) bool {
+			return compareVersions(candidates[i].Version, candidates[j].Version) > 0
+		})
+
+		chosen := candidates[0]
+		if hoisted := hoistedCandidate(candidates); hoisted != nil {
+			chosen = *hoisted
+		} else if depRange, ok := rootRanges[name]; ok {
+			for _, candidate := range candidates {
+				if satisfiesRange(candidate.Version, depRange) {
+					chosen = candidate
+					break
+				}
+			}
+			for _, candidate := range candidates {
+				if containsVersion(candidate.Ranges, depRange) {
+					chosen = candidate
+					break
+				}
+			}
+		}
+
+		versions := make(map[string]bool)
+		for _, candidate := range candidates {
+			versions[candidate.Version] = true
+		}
+		original[name] = sortedSet(versions)
+
+		lockPkg := LockPackage{
+			Name:         name,
+			Version:      chosen.Version,
+			Resolved:     chosen.Resolved,
+			Integrity:    strongestIntegrity(chosen.Integrity),
+			Dependencies: chosen.Dependencies,
+			DevDep:       chosen.Dev,
+			Optional:     chosen.Optional,
+		}
+		lockFile.Packages[fmt.Sprintf("%s@%s", name, chosen.Version)] = lockPkg
+	}
+
+	for name, depRange := range rootRanges {
+		lockFile.Specifiers[name] = depRange
+	}
+	for name, depRange := range pkg.DevDependencies {
+		lockFile.DevPackages[name] = depRange
+	}
+
+	return lockFile, original
+}
+
+func hoistedCandidate(candidates []importedPackage) *importedPackage {
+	for i := range candidates {
+		if candidates[i].Hoisted {
+			return &candidates[i]
+		}
+	}
+	return nil
+}
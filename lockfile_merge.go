@@ -0,0 +1,86 @@
+package main
+
+import "reflect"
+
+func mergeLockFiles(base, ours, theirs *LockFile) *LockFile {
+	merged := newLockFile()
+
+	keys := make(map[string]bool)
+	for key := range ours.Packages {
+		keys[key] = true
+	}
+	for key := range theirs.Packages {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		ourPkg, inOurs := ours.Packages[key]
+		theirPkg, inTheirs := theirs.Packages[key]
+		basePkg, inBase := base.Packages[key]
+		ourChanged := !inBase || !reflect.DeepEqual(ourPkg, basePkg)
+		theirChanged := !inBase || !reflect.DeepEqual(theirPkg, basePkg)
+
+		switch {
+		case inOurs && inTheirs && !ourChanged:
+			merged.Packages[key] = theirPkg
+		case inOurs && inTheirs:
+			merged.Packages[key] = ourPkg
+		case inOurs && ourChanged:
+			merged.Packages[key] = ourPkg
+		case inTheirs && theirChanged:
+			merged.Packages[key] = theirPkg
+		}
+	}
+
+	theirsNewer := func(name string) bool {
+		return compareVersions(directLockedVersion(theirs, name), directLockedVersion(ours, name)) > 0
+	}
+	mergeSpecifiers(merged.Specifiers, base.Specifiers, ours.Specifiers, theirs.Specifiers, theirsNewer)
+	mergeSpecifiers(merged.DevPackages, base.DevPackages, ours.DevPackages, theirs.DevPackages, theirsNewer)
+
+	return merged
+}
+
+func mergeSpecifiers(merged, base, ours, theirs map[string]string, theirsNewer func(name string) bool) {
+	names := make(map[string]bool)
+	for name := range ours {
+		names[name] = true
+	}
+	for name := range theirs {
+		names[name] = true
+	}
+
+	for name := range names {
+		ourSpec, inOurs := ours[name]
+		theirSpec, inTheirs := theirs[name]
+		baseSpec, inBase := base[name]
+		ourChanged := !inBase || ourSpec != baseSpec
+		theirChanged := !inBase || theirSpec != baseSpec
+
+		switch {
+		case inOurs && inTheirs:
+			if ourSpec != theirSpec && (!ourChanged || (theirChanged && theirsNewer(name))) {
+				merged[name] = theirSpec
+			} else {
+				merged[name] = ourSpec
+			}
+		case inOurs && ourChanged:
+			merged[name] = ourSpec
+		case inTheirs && theirChanged:
+			merged[name] = theirSpec
+		}
+	}
+}
+
+func directLockedVersion(lf *LockFile, name string) string {
+	version, direct := "", false
+	for _, pkg := range lf.Packages {
+		if pkg.Name != name || (direct && !pkg.Direct) {
+			continue
+		}
+		if (pkg.Direct && !direct) || version == "" || compareVersions(pkg.Version, version) > 0 {
+			version, direct = pkg.Version, pkg.Direct
+		}
+	}
+	return version
+}
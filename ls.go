@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+type TreeNode struct {
+	Version      string               `json:"version,omitempty"`
+	Resolved     string               `json:"resolved,omitempty"`
+	Dev          bool                 `json:"dev,omitempty"`
+	Hoisted      bool                 `json:"hoisted,omitempty"`
+	Deduped      bool                 `json:"deduped,omitempty"`
+	Missing      bool                 `json:"missing,omitempty"`
+	Optional     bool                 `json:"optional,omitempty"`
+	Skipped      bool                 `json:"skipped,omitempty"`
+	Dependencies map[string]*TreeNode `json:"dependencies,omitempty"`
+}
+
+type DependencyTree struct {
+	Name         string               `json:"name"`
+	Version      string               `json:"version"`
+	Dependencies map[string]*TreeNode `json:"dependencies"`
+}
+
+type treeBuilder struct {
+	nodeModulesPath string
+	lockFile        *LockFile
+	expanded        map[string]bool
+	maxDepth        int
+}
+
+func buildDependencyTree(nodeModulesPath string, pkg *PackageJSON, lockFile *LockFile, maxDepth int) *DependencyTree {
+	builder := &treeBuilder{
+		nodeModulesPath: nodeModulesPath,
+		lockFile:        lockFile,
+		expanded:        make(map[string]bool),
+		maxDepth:        maxDepth,
+	}
+
+	tree := &DependencyTree{
+		Name:         pkg.Name,
+		Version:      pkg.Version,
+		Dependencies: make(map[string]*TreeNode),
+	}
+
+	for _, name := range sortedKeys(pkg.Dependencies) {
+		tree.Dependencies[name] = builder.buildNode(nodeModulesPath, name, 0)
+	}
+	for _, name := range sortedKeys(pkg.DevDependencies) {
+		node := builder.buildNode(nodeModulesPath, name, 0)
+		node.Dev = true
+		tree.Dependencies[name] = node
+	}
+	for _, name := range sortedKeys(pkg.OptionalDependencies) {
+		tree.Dependencies[name] = builder.buildOptionalNode(nodeModulesPath, name, 0)
+	}
+
+	return tree
+}
+
+func (tb *treeBuilder) buildNode(parentPath, name string, depth int) *TreeNode {
+	nested := depth > 0
+	var packagePath string
+	if nested {
+		packagePath = resolveInstalledDependency(tb.nodeModulesPath, parentPath, name)
+	} else {
+		packagePath = filepath.Join(tb.nodeModulesPath, name)
+	}
+
+	manifest, err := readInstalledManifest(packagePath)
+	if packagePath == "" || err != nil {
+		return &TreeNode{Missing: true}
+	}
+
+	node := &TreeNode{
+		Version: manifest.Version,
+		Hoisted: nested && packagePath == filepath.Join(tb.nodeModulesPath, name),
+	}
+
+	tb.lockFile.mu.RLock()
+	if lockPkg, ok := tb.lockFile.Packages[fmt.Sprintf("%s@%s", name, manifest.Version)]; ok {
+		node.Resolved = lockPkg.Resolved
+	}
+	tb.lockFile.mu.RUnlock()
+
+	if tb.maxDepth >= 0 && depth >= tb.maxDepth {
+		return node
+	}
+	if tb.expanded[packagePath] {
+		node.Deduped = true
+		return node
+	}
+	tb.expanded[packagePath] = true
+
+	if len(manifest.Dependencies) > 0 {
+		node.Dependencies = make(map[string]*TreeNode)
+		for _, depName := range sortedKeys(manifest.Dependencies) {
+			node.Dependencies[depName] = tb.buildNode(packagePath, depName, depth+1)
+		}
+	}
+	if len(manifest.OptionalDependencies) > 0 {
+		if node.Dependencies == nil {
+			node.Dependencies = make(map[string]*TreeNode)
+		}
+		for _, depName := range sortedKeys(manifest.OptionalDependencies) {
+			node.Dependencies[depName] = tb.buildOptionalNode(packagePath, depName, depth+1)
+		}
+	}
+
+	return node
+}
+
+func (tb *treeBuilder) buildOptionalNode(parentPath, name string, depth int) *TreeNode {
+	node := tb.buildNode(parentPath, name, depth)
+	node.Optional = true
+	if node.Missing && tb.lockFile.isSkippedOptional(name) {
+		node.Missing = false
+		node.Skipped = true
+	}
+	return node
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedNodeKeys(m map[string]*TreeNode) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func printDependencyTreeJSON(tree *DependencyTree) error {
+	data, err := json.MarshalIndent(tree, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dependency tree: %v", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func printDependencyTree(tree *DependencyTree) {
+	fmt.Printf("\n %s@%s\n", color.CyanString(tree.Name), color.HiBlackString(tree.Version))
+	printTreeNodes(tree.Dependencies, " ")
+	fmt.Println()
+}
+
+func printTreeNodes(nodes map[string]*TreeNode, indent string) {
+	names := sortedNodeKeys(nodes)
+	for i, name := range names {
+		node := nodes[name]
+
+		branch, childIndent := "├── ", indent+"│   "
+		if i == len(names)-1 {
+			branch, childIndent = "└── ", indent+"    "
+		}
+
+		label := fmt.Sprintf("%s@%s", color.CyanString(name), color.HiBlackString(node.Version))
+		switch {
+		case node.Skipped:
+			label = fmt.Sprintf("%s %s", color.CyanString(name), color.HiBlackString("(optional, skipped)"))
+		case node.Missing:
+			label = fmt.Sprintf("%s %s", color.CyanString(name), color.RedString("(missing)"))
+		case node.Deduped:
+			label += color.HiBlackString(" deduped")
+		}
+		if node.Dev {
+			label += color.HiBlackString(" (dev)")
+		}
+
+		fmt.Printf("%s%s%s\n", indent, color.HiBlackString(branch), label)
+		printTreeNodes(node.Dependencies, childIndent)
+	}
+}
@@ -4,36 +4,122 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 )
 
+var commandsWithoutPackageJSON = map[string]bool{
+	"help":           true,
+	"-h":             true,
+	"--help":         true,
+	"version":        true,
+	"-v":             true,
+	"--version":      true,
+	"lockfile-merge": true,
+	"store":          true,
+}
+
 func main() {
 	if len(os.Args) < 2 {
 		printUsage()
 		os.Exit(1)
 	}
 
-	if !fileExists("package.json") {
+	command := os.Args[1]
+	if command == "exec" {
+		os.Args, execArgs = splitExecArgs(os.Args)
+	}
+
+	if prefix := takeFlagValue("--prefix"); prefix != "" {
+		installPrefix = prefix
+	}
+
+	if !commandsWithoutPackageJSON[command] && !fileExists("package.json") && flagValue("--manifest") == "" {
 		color.Red("Error: package.json not found in current directory")
 		color.Yellow("Please run this command in a directory with a package.json file")
 		os.Exit(1)
 	}
 
-	command := os.Args[1]
+	config = loadConfig()
+
+	if !config.getBool("progress", true) {
+		progressDisabled = true
+	}
+	if hasFlag("--no-progress") {
+		progressDisabled = true
+	}
+	if hasFlag("--progress-json") {
+		progressStream = newProgressEmitter(os.Stderr)
+	}
+	if hasFlag("--no-lockfile") {
+		lockFileDisabled = true
+	}
+	if config.getBool("ignore-scripts", false) || hasFlag("--ignore-scripts") {
+		scriptsDisabled = true
+	}
+
+	reporterName := config.get("reporter")
+	if name := flagValue("--reporter"); name != "" {
+		reporterName = name
+	}
+	var err error
+	if reporter, err = newReporter(reporterName); err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
 
 	switch command {
 	case "install", "i", "add":
-		handleInstall()
+		if err := runWithHooks("install", handleInstall); err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
 	case "uninstall", "remove", "rm":
 		handleUninstall()
 	case "upgrade", "update":
-		handleUpgrade()
+		if err := runWithHooks("upgrade", handleUpgrade); err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+	case "update-lock":
+		handleUpdateLock()
+	case "verify":
+		handleVerify()
+	case "outdated":
+		handleOutdated()
+	case "audit":
+		handleAudit()
+	case "ls", "list", "tree":
+		handleList()
+	case "graph":
+		handleGraph()
+	case "migrate":
+		handleMigrate()
+	case "clean":
+		handleClean()
+	case "lockfile-merge":
+		handleLockfileMerge()
 	case "cache":
 		handleCache()
+	case "store":
+		handleStore()
 	case "bin":
 		handleBin()
+	case "exec":
+		handleExec()
+	case "info":
+		handleInfo()
+	case "add-script":
+		handleAddScript()
+	case "remove-script":
+		handleRemoveScript()
+	case "version", "-v", "--version":
+		fmt.Println(currentGpmVersion())
 	case "help", "-h", "--help":
 		printUsage()
 	default:
@@ -46,54 +132,451 @@ func main() {
 func handleInstall() {
 	pm := NewPackageManager()
 
-	lockFile, err := loadLockFile()
-	if err != nil {
-		color.Red("Failed to load lockfile: %v", err)
-		os.Exit(1)
-	}
-
-	if len(os.Args) < 3 {
-		if err := installFromPackageJSON(pm, lockFile); err != nil {
-			color.Red("Failed to install packages: %v", err)
+	var lockFile *LockFile
+	var err error
+	if hasFlag("--fix-lockfile") {
+		lockFile, err = regenerateLockFile(pm.nodeModulesPath, pm.cache)
+		if err != nil {
+			color.Red("Failed to regenerate lockfile: %v", err)
+			os.Exit(1)
+		}
+		fmt.Printf(" %s Regenerated %s from node_modules\n", color.HiGreenString("✓"), lockFileName())
+	} else {
+		lockFile, err = loadLockFile()
+		if err != nil {
+			color.Red("Failed to load lockfile: %v", err)
 			os.Exit(1)
 		}
-		return
 	}
 
-	timer := NewTimer()
-	timer.Start()
+	if hasFlag("--dedupe-report") {
+		printDedupeReportFor(pm, lockFile)
+		return
+	}
 
 	packages := []string{}
 	isDev := false
+	runAudit := config.getBool("audit", false)
+	auditLevel := config.getDefault("audit-level", "low")
+	verifyTree := config.getBool("verify-tree", false)
+	strictTree := false
+
+	manifestPath := projectManifestPath()
+	maxRate := config.get("max-rate")
+	depsOf := ""
+	auditFix := false
+	allowMajor := false
+	cleanModules := false
+	frozenLockfile := false
+	save := config.getBool("save", true)
 
 	for i := 2; i < len(os.Args); i++ {
 		arg := os.Args[i]
 		if arg == "--save-dev" || arg == "-D" {
 			isDev = true
+			save = true
+		} else if arg == "--save" {
+			save = true
+		} else if arg == "--no-save" {
+			save = false
+		} else if strings.HasPrefix(arg, "--reporter=") {
+		} else if arg == "--reporter" && i+1 < len(os.Args) {
+			i++
+		} else if arg == "--audit" {
+			runAudit = true
+		} else if arg == "--no-audit" {
+			runAudit = false
+		} else if arg == "--audit-fix" {
+			auditFix = true
+		} else if arg == "--allow-major" {
+			allowMajor = true
+		} else if arg == "--force" {
+			pm.force = true
+		} else if arg == "--clean" {
+			cleanModules = true
+		} else if arg == "--no-verify" {
+			pm.noVerify = true
+		} else if strings.HasPrefix(arg, "--audit-level=") {
+			auditLevel = strings.TrimPrefix(arg, "--audit-level=")
+		} else if strings.HasPrefix(arg, "--audit-registry=") {
+			pm.auditRegistry = strings.TrimPrefix(arg, "--audit-registry=")
+		} else if arg == "--audit-registry" && i+1 < len(os.Args) {
+			pm.auditRegistry = os.Args[i+1]
+			i++
+		} else if arg == "--frozen" {
+			pm.frozenLock = lockFile
+		} else if arg == "--frozen-lockfile" {
+			frozenLockfile = true
+		} else if arg == "--offline" {
+			pm.offline = true
+		} else if arg == "--prefer-offline" {
+			pm.preferOffline = true
+		} else if strings.HasPrefix(arg, "--manifest=") {
+			manifestPath = strings.TrimPrefix(arg, "--manifest=")
+		} else if arg == "--manifest" && i+1 < len(os.Args) {
+			manifestPath = os.Args[i+1]
+			i++
+		} else if strings.HasPrefix(arg, "--fetch-timeout=") {
+			pm.fetchTimeout = parseTimeoutFlag(strings.TrimPrefix(arg, "--fetch-timeout="))
+		} else if arg == "--fetch-timeout" && i+1 < len(os.Args) {
+			pm.fetchTimeout = parseTimeoutFlag(os.Args[i+1])
+			i++
+		} else if strings.HasPrefix(arg, "--concurrency=") {
+			pm.concurrency = min(parseCountFlag("concurrency", strings.TrimPrefix(arg, "--concurrency=")), maxConcurrency)
+		} else if arg == "--concurrency" && i+1 < len(os.Args) {
+			pm.concurrency = min(parseCountFlag("concurrency", os.Args[i+1]), maxConcurrency)
+			i++
+		} else if strings.HasPrefix(arg, "--fetch-attempts=") {
+			pm.fetchAttempts = parseCountFlag("attempts", strings.TrimPrefix(arg, "--fetch-attempts="))
+		} else if arg == "--fetch-attempts" && i+1 < len(os.Args) {
+			pm.fetchAttempts = parseCountFlag("attempts", os.Args[i+1])
+			i++
+		} else if strings.HasPrefix(arg, "--download-timeout=") {
+			pm.downloadTimeout = parseTimeoutFlag(strings.TrimPrefix(arg, "--download-timeout="))
+		} else if arg == "--download-timeout" && i+1 < len(os.Args) {
+			pm.downloadTimeout = parseTimeoutFlag(os.Args[i+1])
+			i++
+		} else if strings.HasPrefix(arg, "--max-rate=") {
+			maxRate = strings.TrimPrefix(arg, "--max-rate=")
+		} else if arg == "--max-rate" && i+1 < len(os.Args) {
+			maxRate = os.Args[i+1]
+			i++
+		} else if strings.HasPrefix(arg, "--deps-of=") {
+			depsOf = strings.TrimPrefix(arg, "--deps-of=")
+		} else if arg == "--deps-of" && i+1 < len(os.Args) {
+			depsOf = os.Args[i+1]
+			i++
+		} else if arg == "--check-files" {
+			pm.checkFiles = true
+		} else if arg == "--only-missing" {
+			pm.onlyMissing = true
+		} else if arg == "--install-peers" {
+			pm.installPeers = true
+		} else if arg == "--timings" {
+			pm.timings = defaultTimingsCount
+		} else if strings.HasPrefix(arg, "--timings=") {
+			count, err := strconv.Atoi(strings.TrimPrefix(arg, "--timings="))
+			if err != nil || count < 1 {
+				color.Red("Invalid timings count: %s (expected a positive number)", strings.TrimPrefix(arg, "--timings="))
+				os.Exit(1)
+			}
+			pm.timings = count
+		} else if strings.HasPrefix(arg, "--before=") {
+			pm.before = parseDateFlag(strings.TrimPrefix(arg, "--before="))
+		} else if arg == "--before" && i+1 < len(os.Args) {
+			pm.before = parseDateFlag(os.Args[i+1])
+			i++
+		} else if arg == "--strict-ranges" {
+			pm.strictRanges = true
+		} else if arg == "--engine-strict" {
+			pm.engineStrict = true
+		} else if arg == "--ignore-engines" {
+			pm.ignoreEngines = true
+		} else if arg == "--verify-tree" {
+			verifyTree = true
+		} else if arg == "--strict" {
+			verifyTree = true
+			strictTree = true
 		} else if !strings.HasPrefix(arg, "--") {
 			packages = append(packages, arg)
 		}
 	}
 
-	if len(packages) == 0 {
-		color.Red("Error: Please specify a package to install")
+	if !validAuditLevel(auditLevel) {
+		color.Red("Invalid audit level: %s (expected info, low, moderate, high or critical)", auditLevel)
+		os.Exit(1)
+	}
+
+	if maxRate != "" {
+		bytesPerSecond, err := parseByteRate(maxRate)
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+		pm.downloadLimiter = newRateLimiter(bytesPerSecond)
+	}
+
+	if pm.offline || pm.preferOffline {
+		pm.offlineLock = lockFile
+	}
+	if pm.offline && (runAudit || auditFix) {
+		reportWarning("Skipping audit because --offline is set")
+		runAudit, auditFix = false, false
+	}
+
+	if frozenLockfile {
+		if len(packages) > 0 || depsOf != "" {
+			color.Red("--frozen-lockfile cannot add packages; update package.json and %s first", lockFileName())
+			os.Exit(1)
+		}
+		pkg, err := loadPackageJSON(manifestPath)
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+		if mismatches := checkFrozenLockfile(pkg, lockFile); len(mismatches) > 0 {
+			printLockfileMismatches(mismatches)
+			os.Exit(1)
+		}
+		pm.frozenLock = lockFile
+		lockFile.readOnly = true
+	}
+
+	if err := preflightForeignNodeModules(pm.nodeModulesPath, cleanModules); err != nil {
+		color.Red("%v", err)
 		os.Exit(1)
 	}
 
+	if depsOf != "" {
+		installDependenciesOf(pm, lockFile, depsOf)
+		return
+	}
+
+	if len(packages) == 0 {
+		if err := installFromPackageJSON(pm, lockFile, manifestPath); err != nil {
+			color.Red("Failed to install packages: %s", redactSecrets(err.Error()))
+			os.Exit(1)
+		}
+		if verifyTree {
+			verifyTreeAfterInstall(pm, strictTree)
+		}
+		if auditFix {
+			auditFixAfterInstall(pm, lockFile, auditLevel, allowMajor)
+		} else if runAudit {
+			auditAfterInstall(pm, lockFile, auditLevel)
+		}
+		return
+	}
+
+	packages, gitPackages := splitGitSpecs(packages)
+	for _, spec := range gitPackages {
+		if _, err := installGitPackage(pm, lockFile, spec, "", isDev, save); err != nil {
+			color.Red("Failed to install %s: %s", redactSecrets(spec), redactSecrets(err.Error()))
+			os.Exit(1)
+		}
+	}
+	pm.reportDependencyFailures()
+	if len(packages) == 0 {
+		if err := lockFile.saveLockFile(); err != nil {
+			reportWarning("Failed to save lockfile: %v", err)
+		}
+		return
+	}
+
+	timer := NewTimer()
+	timer.Start()
 
 	parallelInstaller := NewParallelInstaller(pm, lockFile, timer)
-	if err := parallelInstaller.InstallFromSpecs(packages, isDev, true); err != nil {
-		color.Red("Failed to install packages: %v", err)
+	installErr := parallelInstaller.InstallFromSpecs(packages, isDev, save)
+	if _, partial := installErr.(*partialInstallError); installErr != nil && !partial {
+		color.Red("Failed to install packages: %s", redactSecrets(installErr.Error()))
 		os.Exit(1)
 	}
 
 	elapsed := timer.Stop()
 
 	if err := lockFile.saveLockFile(); err != nil {
-		fmt.Printf(" %s Failed to save lockfile: %v\n", color.YellowString("⚠"), err)
+		reportWarning("Failed to save lockfile: %v", err)
+	}
+
+	reporter.Report(InstallEvent{Type: "done", ElapsedMs: elapsed.Milliseconds()})
+	if !save && reporter.Human() {
+		fmt.Printf(" %s package.json was not modified (pass --save to record the packages)\n", color.HiBlackString("ℹ"))
+	}
+
+	if verifyTree {
+		verifyTreeAfterInstall(pm, strictTree)
+	}
+	if auditFix {
+		auditFixAfterInstall(pm, lockFile, auditLevel, allowMajor)
+	} else if runAudit {
+		auditAfterInstall(pm, lockFile, auditLevel)
+	}
+
+	if installErr != nil {
+		color.Red("Failed to install packages: %s", redactSecrets(installErr.Error()))
+		os.Exit(1)
+	}
+}
+
+func printDedupeReportFor(pm *PackageManager, lockFile *LockFile) {
+	pkg, err := loadPackageJSON(projectManifestPath())
+	if err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+
+	report, err := buildDedupeReport(pm.nodeModulesPath, pkg, lockFile)
+	if err != nil {
+		color.Red("Failed to analyze duplicates: %v", err)
+		os.Exit(1)
+	}
+
+	if hasFlag("--json") {
+		if err := printDedupeReportJSON(report); err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+		return
+	}
+	printDedupeReport(report)
+}
+
+func installDependenciesOf(pm *PackageManager, lockFile *LockFile, packageName string) {
+	manifest, err := readInstalledManifest(filepath.Join(pm.nodeModulesPath, packageName))
+	if err != nil {
+		color.Red("%s is not installed", packageName)
+		os.Exit(1)
+	}
+
+	timer := NewTimer()
+	timer.Start()
+	installed := pm.installDependencyTree(packageName, lockFile, make(map[string]bool))
+	elapsed := timer.Stop()
+	pm.reportDependencyFailures()
+
+	if len(installed) == 0 {
+		fmt.Printf(" %s All dependencies of %s@%s are installed\n", color.HiGreenString("✓"), color.CyanString(packageName), color.HiBlackString(manifest.Version))
+	} else {
+		fmt.Printf(" %s Reinstalled %d dependencies of %s@%s:\n", color.HiGreenString("✓"), len(installed), color.CyanString(packageName), color.HiBlackString(manifest.Version))
+		for _, pkg := range installed {
+			fmt.Printf("   %s\n", color.CyanString(pkg))
+		}
+	}
+
+	if err := NewBinaryManager().setupAllBinaries(); err != nil {
+		reportWarning("Failed to setup some binaries: %v", err)
+	}
+	if err := lockFile.saveLockFile(); err != nil {
+		reportWarning("Failed to save lockfile: %v", err)
+	}
+
+	reporter.Report(InstallEvent{Type: "done", ElapsedMs: elapsed.Milliseconds()})
+}
+
+func verifyTreeAfterInstall(pm *PackageManager, strict bool) {
+	problems, err := checkInstalledTree(pm.nodeModulesPath)
+	if err != nil {
+		fmt.Printf(" %s Failed to verify dependency tree: %v\n", color.YellowString("⚠"), err)
+		return
+	}
+
+	printTreeProblems(problems)
+	if strict && len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+func auditAfterInstall(pm *PackageManager, lockFile *LockFile, level string) {
+	advisories, err := pm.auditPackages(lockFile)
+	if err != nil {
+		fmt.Printf(" %s Audit failed: %v\n", color.YellowString("⚠"), err)
+		return
+	}
+	printAuditSummary(filterAdvisories(advisories, level))
+}
+
+func auditFixAfterInstall(pm *PackageManager, lockFile *LockFile, level string, allowMajor bool) {
+	advisories, err := pm.auditPackages(lockFile)
+	if err != nil {
+		color.Red("Audit failed: %s", redactSecrets(err.Error()))
+		os.Exit(1)
+	}
+	advisories = filterAdvisories(advisories, level)
+	if len(advisories) == 0 {
+		printAuditSummary(advisories)
+		return
+	}
+
+	fixes, unfixed, err := planAuditFixes(pm, lockFile, advisories, allowMajor)
+	if err != nil {
+		color.Red("Failed to plan audit fixes: %v", err)
+		os.Exit(1)
+	}
+
+	upgrades := make([]UpgradeInfo, 0, len(fixes))
+	for _, fix := range fixes {
+		lockFile.removePackageVersion(fix.Name, fix.FromVersion)
+		upgrades = append(upgrades, UpgradeInfo{
+			Name:           fix.Name,
+			CurrentVersion: fix.FromVersion,
+			LatestVersion:  fix.ToVersion,
+			NeedsUpgrade:   true,
+			IsDev:          fix.IsDev,
+		})
+	}
+
+	var fixErr error
+	if len(upgrades) > 0 {
+		fixErr = NewParallelInstaller(pm, lockFile, nil).InstallUpgrades(upgrades)
+	}
+
+	if err := lockFile.saveLockFile(); err != nil {
+		reportWarning("Failed to save lockfile: %v", err)
+	}
+
+	printAuditFixes(fixes, unfixed)
+
+	if fixErr != nil {
+		color.Red("Failed to apply some fixes: %s", redactSecrets(fixErr.Error()))
+		os.Exit(1)
+	}
+	if len(unfixed) > 0 {
+		os.Exit(1)
+	}
+}
+
+func handleAudit() {
+	level := config.getDefault("audit-level", "low")
+	jsonOutput := false
+
+	pm := NewPackageManager()
+	for i := 2; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		if strings.HasPrefix(arg, "--audit-level=") {
+			level = strings.TrimPrefix(arg, "--audit-level=")
+		} else if arg == "--json" {
+			jsonOutput = true
+		} else if strings.HasPrefix(arg, "--audit-registry=") {
+			pm.auditRegistry = strings.TrimPrefix(arg, "--audit-registry=")
+		} else if arg == "--audit-registry" && i+1 < len(os.Args) {
+			pm.auditRegistry = os.Args[i+1]
+			i++
+		}
+	}
+
+	if !validAuditLevel(level) {
+		color.Red("Invalid audit level: %s (expected info, low, moderate, high or critical)", level)
+		os.Exit(1)
+	}
+
+	lockFile, err := loadLockFile()
+	if err != nil {
+		color.Red("Failed to load lockfile: %v", err)
+		os.Exit(1)
+	}
+
+	advisories, err := pm.auditPackages(lockFile)
+	if err != nil {
+		color.Red("Failed to audit packages: %s", redactSecrets(err.Error()))
+		os.Exit(1)
+	}
+	advisories = filterAdvisories(advisories, level)
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(advisories, "", "  ")
+		if err != nil {
+			color.Red("Failed to marshal audit report: %v", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	} else {
+		printAuditSummary(advisories)
 	}
 
-	fmt.Printf(" %s Done in %s\n", color.HiGreenString("✓"), color.HiBlackString(formatDuration(elapsed)))
+	if len(advisories) > 0 {
+		os.Exit(1)
+	}
 }
 
 func handleUninstall() {
@@ -108,9 +591,25 @@ func handleUninstall() {
 		os.Exit(1)
 	}
 
-	packages := os.Args[2:]
+	group := ""
+	var packages []string
+	for _, arg := range os.Args[2:] {
+		if arg == "--save-dev" || arg == "-D" {
+			group = groupDevDependencies
+		} else if arg == "--save-prod" || arg == "-P" {
+			group = groupDependencies
+		} else if !strings.HasPrefix(arg, "-") {
+			packages = append(packages, arg)
+		}
+	}
+
+	if len(packages) == 0 {
+		color.Red("Error: Please specify a package to uninstall")
+		os.Exit(1)
+	}
+
 	for _, packageName := range packages {
-		if err := uninstallPackage(packageName, lockFile); err != nil {
+		if err := uninstallPackage(packageName, group, lockFile); err != nil {
 			color.Red("Failed to uninstall %s: %v", packageName, err)
 			os.Exit(1)
 		}
@@ -123,6 +622,64 @@ func handleUninstall() {
 	fmt.Printf(" %s Uninstalled %d package(s)\n", color.HiGreenString("✓"), len(packages))
 }
 
+func handleAddScript() {
+	if len(os.Args) != 4 || os.Args[2] == "" {
+		color.Red("Usage: gpm add-script <name> <command>")
+		os.Exit(1)
+	}
+
+	name, command := os.Args[2], os.Args[3]
+	pkg, err := loadPackageJSON("package.json")
+	if err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+	previous, exists := pkg.Scripts[name]
+
+	err = editManifestFile("package.json", func(data []byte) ([]byte, error) {
+		return setManifestEntry(data, "scripts", name, command)
+	})
+	if err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case !exists:
+		fmt.Printf(" %s Added script %s: %s\n", color.HiGreenString("✓"), color.CyanString(name), color.HiBlackString(command))
+	case previous == command:
+		fmt.Printf(" %s Script %s is already %s\n", color.HiBlackString("ℹ"), color.CyanString(name), color.HiBlackString(command))
+	default:
+		fmt.Printf(" %s Updated script %s: %s %s %s\n", color.HiGreenString("✓"), color.CyanString(name), color.RedString(previous), color.BlueString("→"), color.GreenString(command))
+	}
+}
+
+func handleRemoveScript() {
+	if len(os.Args) < 3 {
+		color.Red("Usage: gpm remove-script <name>...")
+		os.Exit(1)
+	}
+
+	for _, name := range os.Args[2:] {
+		removed := false
+		err := editManifestFile("package.json", func(data []byte) ([]byte, error) {
+			updated, ok, err := removeManifestEntry(data, "scripts", name)
+			removed = ok
+			return updated, err
+		})
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+
+		if removed {
+			fmt.Printf(" %s Removed script %s\n", color.HiGreenString("✓"), color.CyanString(name))
+		} else {
+			fmt.Printf(" %s No script named %s\n", color.YellowString("⚠"), color.CyanString(name))
+		}
+	}
+}
+
 func handleUpgrade() {
 	if !fileExists("package.json") {
 		color.Red("Error: package.json not found in current directory")
@@ -140,19 +697,30 @@ func handleUpgrade() {
 
 
 	skipTUI := false
+	dryRun := false
+	jsonOutput := false
+	includePinned := false
 	var packagesToUpgrade []string
 
 	if len(os.Args) > 2 {
 		for _, arg := range os.Args[2:] {
 			if arg == "--all" || arg == "-a" {
 				skipTUI = true
-			} else {
+			} else if arg == "--latest" {
+				includePinned = true
+			} else if arg == "--dry-run" {
+				dryRun = true
+			} else if arg == "--json" {
+				jsonOutput = true
+			} else if !strings.HasPrefix(arg, "--") {
 				packagesToUpgrade = append(packagesToUpgrade, arg)
 			}
 		}
 	}
 
-	if len(packagesToUpgrade) == 0 && !skipTUI {
+	if len(packagesToUpgrade) > 0 {
+		includePinned = true
+	} else {
 
 		data, err := os.ReadFile("package.json")
 		if err != nil {
@@ -161,7 +729,7 @@ func handleUpgrade() {
 		}
 
 		var pkg PackageJSON
-		if err := json.Unmarshal(data, &pkg); err != nil {
+		if err := parseManifest(data, &pkg); err != nil {
 			color.Red("Failed to parse package.json: %v", err)
 			os.Exit(1)
 		}
@@ -182,15 +750,39 @@ func handleUpgrade() {
 
 	upgrades, err := upgradeManager.CheckUpgrades(packagesToUpgrade)
 	if err != nil {
-		color.Red("Failed to check for upgrades: %v", err)
+		color.Red("Failed to check for upgrades: %s", redactSecrets(err.Error()))
 		os.Exit(1)
 	}
 
+	if dryRun {
+		if jsonOutput {
+			if err := printOutdatedJSON(collectOutdated(upgrades)); err != nil {
+				color.Red("%v", err)
+				os.Exit(1)
+			}
+			return
+		}
+		upgradeManager.ShowUpgradePreview(upgrades)
+		if !includePinned {
+			_, pinned := excludePinned(upgrades)
+			printSkippedPinned(pinned)
+		}
+		fmt.Printf(" %s Dry run: nothing was installed or written\n", color.HiBlackString("ℹ"))
+		return
+	}
+
 	var packagesNeedingUpgrade []string
 
 	if skipTUI {
 
-		for _, upgrade := range upgrades {
+		candidates := upgrades
+		if !includePinned {
+			var pinned []UpgradeInfo
+			candidates, pinned = excludePinned(upgrades)
+			printSkippedPinned(pinned)
+		}
+
+		for _, upgrade := range candidates {
 			if upgrade.NeedsUpgrade {
 				packagesNeedingUpgrade = append(packagesNeedingUpgrade, upgrade.Name)
 			}
@@ -205,7 +797,7 @@ func handleUpgrade() {
 	} else {
 
 		tui := NewTUI()
-		selectedUpgrades, err := tui.SelectPackagesToUpgrade(upgrades)
+		selectedUpgrades, err := tui.SelectPackagesToUpgrade(upgrades, includePinned)
 		if err != nil {
 			color.Red("Failed to select packages: %v", err)
 			os.Exit(1)
@@ -226,8 +818,9 @@ func handleUpgrade() {
 
 
 	parallelInstaller := NewParallelInstaller(pm, lockFile, timer)
-	if err := parallelInstaller.InstallFromSpecs(packagesNeedingUpgrade, false, true); err != nil {
-		color.Red("Failed to upgrade packages: %v", err)
+	installErr := parallelInstaller.InstallFromSpecs(packagesNeedingUpgrade, false, true)
+	if _, partial := installErr.(*partialInstallError); installErr != nil && !partial {
+		color.Red("Failed to upgrade packages: %s", redactSecrets(installErr.Error()))
 		os.Exit(1)
 	}
 
@@ -237,29 +830,551 @@ func handleUpgrade() {
 		fmt.Printf(" %s Failed to save lockfile: %v\n", color.YellowString("⚠"), err)
 	}
 
+	if installErr != nil {
+		color.Red("Failed to upgrade packages: %s", redactSecrets(installErr.Error()))
+		os.Exit(1)
+	}
+
 	fmt.Printf(" %s Upgraded %d package(s) in %s\n", color.HiGreenString("✓"), len(packagesNeedingUpgrade), color.HiBlackString(formatDuration(elapsed)))
 }
 
-func handleBin() {
-	bm := NewBinaryManager()
-	binaries, err := bm.listBinaries()
+func handleUpdateLock() {
+	lockFile, err := loadLockFile()
 	if err != nil {
-		color.Red("Failed to list binaries: %v", err)
+		color.Red("Failed to load lockfile: %v", err)
 		os.Exit(1)
 	}
 
-	if len(binaries) == 0 {
-		fmt.Printf("\n %s No binaries found\n", color.HiBlackString("ℹ"))
-		return
+	updated, changes, err := updateLockFile(NewPackageManager(), lockFile)
+	if err != nil {
+		color.Red("Failed to update lockfile: %s", redactSecrets(err.Error()))
+		os.Exit(1)
 	}
 
-	fmt.Printf("\n %s Available binaries (%d)\n", color.CyanString("🔧"), len(binaries))
-	for _, binary := range binaries {
-		fmt.Printf("   %s\n", color.CyanString(binary))
-	}
+	printLockChanges(changes)
+
+	if err := updated.saveLockFile(); err != nil {
+		color.Red("Failed to save lockfile: %v", err)
+		os.Exit(1)
+	}
+}
+
+func handleVerify() {
+	if lockFileDisabled || !fileExists(lockFileName()) {
+		color.Red("No %s to verify against", lockFileName())
+		os.Exit(1)
+	}
+
+	lockFile, err := loadLockFile()
+	if err != nil {
+		color.Red("Failed to load lockfile: %v", err)
+		os.Exit(1)
+	}
+
+	problems, lockedCount, err := verifyInstall(nodeModulesDir(), lockFile, NewCache(), hasFlag("--integrity"))
+	if err != nil {
+		color.Red("Failed to verify node_modules: %v", err)
+		os.Exit(1)
+	}
+
+	printVerifyProblems(problems, lockedCount)
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+func handleOutdated() {
+	jsonOutput := false
+	minSeverity := "patch"
+	depth := 0
+	var packageNames []string
+
+	for _, arg := range os.Args[2:] {
+		switch {
+		case arg == "--json":
+			jsonOutput = true
+		case arg == "--depth":
+			depth = -1
+		case strings.HasPrefix(arg, "--depth="):
+			value, err := strconv.Atoi(strings.TrimPrefix(arg, "--depth="))
+			if err != nil || value < 0 {
+				color.Red("Invalid depth: %s (expected a non-negative number)", strings.TrimPrefix(arg, "--depth="))
+				os.Exit(1)
+			}
+			depth = value
+		case arg == "--exit-code":
+		case strings.HasPrefix(arg, "--exit-code="):
+			minSeverity = strings.TrimPrefix(arg, "--exit-code=")
+			if _, ok := severityRank[minSeverity]; !ok {
+				color.Red("Invalid severity: %s (expected patch, minor or major)", minSeverity)
+				os.Exit(1)
+			}
+		case !strings.HasPrefix(arg, "--"):
+			packageNames = append(packageNames, arg)
+		}
+	}
+
+	if len(packageNames) == 0 {
+		pkg, err := loadPackageJSON("package.json")
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+
+		for name := range pkg.Dependencies {
+			packageNames = append(packageNames, name)
+		}
+		for name := range pkg.DevDependencies {
+			packageNames = append(packageNames, name)
+		}
+	}
+
+	lockFile, err := loadLockFile()
+	if err != nil {
+		color.Red("Failed to load lockfile: %v", err)
+		os.Exit(1)
+	}
+
+	upgradeManager := NewUpgradeManager(NewPackageManager(), lockFile)
+	upgrades, err := upgradeManager.CheckUpgrades(packageNames)
+	if err != nil {
+		color.Red("Failed to check for upgrades: %s", redactSecrets(err.Error()))
+		os.Exit(1)
+	}
+
+	entries := collectOutdated(upgrades)
+
+	if depth != 0 {
+		latestVersions := make(map[string]string)
+		for _, pkg := range collectTransitive(lockFile, depth) {
+			if _, ok := entries[pkg.Name]; ok {
+				continue
+			}
+
+			latest, ok := latestVersions[pkg.Name]
+			if !ok {
+				latest, _ = upgradeManager.getLatestVersion(pkg.Name)
+				latestVersions[pkg.Name] = latest
+			}
+			if latest == "" || compareVersions(pkg.Version, latest) >= 0 {
+				continue
+			}
+
+			entries[pkg.Name] = OutdatedEntry{
+				Current:  pkg.Version,
+				Latest:   latest,
+				Severity: upgradeSeverity(pkg.Version, latest),
+				Type:     "transitive",
+				Parents:  pkg.Parents,
+			}
+		}
+	}
+
+	if jsonOutput {
+		if err := printOutdatedJSON(entries); err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+	} else {
+		printOutdatedTable(entries)
+	}
+
+	if hasOutdatedAtSeverity(entries, minSeverity) {
+		os.Exit(1)
+	}
+}
+
+func handleList() {
+	jsonOutput := false
+	duplicates := false
+	depth := -1
+	for _, arg := range os.Args[2:] {
+		if arg == "--json" {
+			jsonOutput = true
+		} else if arg == "--duplicates" {
+			duplicates = true
+		} else if strings.HasPrefix(arg, "--depth=") {
+			value, err := strconv.Atoi(strings.TrimPrefix(arg, "--depth="))
+			if err != nil || value < 0 {
+				color.Red("Invalid depth: %s (expected a non-negative number)", strings.TrimPrefix(arg, "--depth="))
+				os.Exit(1)
+			}
+			depth = value
+		}
+	}
+
+	pkg, err := loadPackageJSON("package.json")
+	if err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+
+	lockFile, err := loadLockFile()
+	if err != nil {
+		color.Red("Failed to load lockfile: %v", err)
+		os.Exit(1)
+	}
+
+	if duplicates {
+		found := findDuplicates(NewPackageManager().nodeModulesPath, pkg, lockFile)
+		if jsonOutput {
+			if err := printDuplicatesJSON(found); err != nil {
+				color.Red("%v", err)
+				os.Exit(1)
+			}
+			return
+		}
+		printDuplicates(found)
+		return
+	}
+
+	tree := buildDependencyTree(NewPackageManager().nodeModulesPath, pkg, lockFile, depth)
+
+	if jsonOutput {
+		if err := printDependencyTreeJSON(tree); err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	printDependencyTree(tree)
+}
+
+func handleGraph() {
+	format := "dot"
+	for i := 2; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		if strings.HasPrefix(arg, "--format=") {
+			format = strings.TrimPrefix(arg, "--format=")
+		} else if arg == "--format" && i+1 < len(os.Args) {
+			format = os.Args[i+1]
+			i++
+		} else if arg == "--json" {
+			format = "json"
+		}
+	}
+	if format != "dot" && format != "json" {
+		color.Red("Invalid graph format: %s (expected dot or json)", format)
+		os.Exit(1)
+	}
+
+	pkg, err := loadPackageJSON("package.json")
+	if err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+
+	lockFile, err := loadLockFile()
+	if err != nil {
+		color.Red("Failed to load lockfile: %v", err)
+		os.Exit(1)
+	}
+
+	graph := buildDependencyGraph(pkg, lockFile)
+	if format == "json" {
+		if err := printGraphJSON(graph); err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+		return
+	}
+	writeGraphDOT(os.Stdout, graph)
+}
+
+func handleMigrate() {
+	skipConfirm := false
+	force := false
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--yes", "-y":
+			skipConfirm = true
+		case "--force":
+			force = true
+		}
+	}
+
+	pkg, err := loadPackageJSON("package.json")
+	if err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+
+	if fileExists(lockFileName()) && !force {
+		color.Red("%s already exists; pass --force to replace it with an imported lockfile", lockFileName())
+		os.Exit(1)
+	}
+
+	pm := NewPackageManager()
+	lockfiles := detectForeignLockfiles()
+	modulesTool, _ := detectForeignNodeModules(pm.nodeModulesPath)
+	if len(lockfiles) == 0 && modulesTool == "" {
+		color.Red("No npm, yarn or pnpm lockfile or node_modules found; run gpm install instead")
+		os.Exit(1)
+	}
+
+	lockFile := newLockFile()
+	original := map[string][]string{}
+	source := modulesTool + " node_modules"
+	if len(lockfiles) > 0 {
+		source = lockfiles[0].File
+		imported, err := lockfiles[0].read()
+		if err != nil {
+			color.Red("%v", err)
+			os.Exit(1)
+		}
+		lockFile, original = buildImportedLockFile(imported, pkg)
+		fmt.Printf(" %s Imported %d packages from %s (%s)\n", color.HiGreenString("✓"), len(lockFile.Packages), source, lockfiles[0].Tool)
+	}
+
+	var targets []string
+	if fileExists(pm.nodeModulesPath) {
+		targets = append(targets, pm.nodeModulesPath)
+	}
+	for _, lockfile := range lockfiles {
+		targets = append(targets, lockfile.File)
+	}
+
+	if !skipConfirm {
+		tui := NewTUI()
+		if !tui.ConfirmAction(fmt.Sprintf("Remove %s and install with gpm?", strings.Join(targets, " and "))) {
+			fmt.Printf(" %s Aborted\n", color.YellowString("ℹ"))
+			return
+		}
+	}
+
+	if err := lockFile.saveLockFile(); err != nil {
+		color.Red("Failed to write %s: %v", lockFileName(), err)
+		os.Exit(1)
+	}
+	if err := os.RemoveAll(pm.nodeModulesPath); err != nil {
+		color.Red("Failed to remove %s: %v", pm.nodeModulesPath, err)
+		os.Exit(1)
+	}
+
+	pm.onlyMissing = true
+	if err := installMigratedProject(pm, lockFile, pkg); err != nil {
+		color.Red("Migration install failed: %s", redactSecrets(err.Error()))
+		if len(lockfiles) > 0 {
+			fmt.Printf(" %s Kept %s so you can go back\n", color.HiBlackString("ℹ"), source)
+		}
+		os.Exit(1)
+	}
+
+	if len(original) > 0 {
+		differences, added := findMigrationDifferences(pm.nodeModulesPath, original)
+		printMigrationDifferences(source, differences, added)
+
+		pruned := false
+		for _, difference := range differences {
+			if difference.Installed == "" && !lockFile.isSkippedOptional(difference.Name) {
+				lockFile.removePackage(difference.Name)
+				pruned = true
+			}
+		}
+		if pruned {
+			if err := lockFile.saveLockFile(); err != nil {
+				color.Red("Failed to save lockfile: %v", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	for _, lockfile := range lockfiles {
+		if err := os.Remove(lockfile.path()); err != nil {
+			color.Red("Failed to remove %s: %v", lockfile.File, err)
+			os.Exit(1)
+		}
+		fmt.Printf(" %s %s %s\n", color.HiGreenString("✓"), lockfile.File, color.RedString("removed"))
+	}
+	fmt.Printf(" %s Migrated to gpm; commit %s\n", color.HiGreenString("✓"), lockFileName())
+}
+
+func handleClean() {
+	removeLock := false
+	skipConfirm := false
+	dryRun := false
+
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--lock":
+			removeLock = true
+		case "--yes", "-y":
+			skipConfirm = true
+		case "--dry-run":
+			dryRun = true
+		}
+	}
+
+	var targets []string
+	if fileExists(nodeModulesDir()) {
+		targets = append(targets, nodeModulesDir())
+	}
+	if removeLock && fileExists(lockFileName()) {
+		targets = append(targets, lockFileName())
+	}
+
+	if len(targets) == 0 {
+		fmt.Printf(" %s Nothing to clean\n", color.HiBlackString("ℹ"))
+		return
+	}
+
+	if dryRun {
+		fmt.Printf(" %s Would remove:\n", color.CyanString("ℹ"))
+		for _, target := range targets {
+			fmt.Printf("   %s\n", target)
+		}
+		return
+	}
+
+	if !skipConfirm {
+		tui := NewTUI()
+		if !tui.ConfirmAction(fmt.Sprintf("Remove %s?", strings.Join(targets, " and "))) {
+			fmt.Printf(" %s Aborted\n", color.YellowString("ℹ"))
+			return
+		}
+	}
+
+	for _, target := range targets {
+		if err := os.RemoveAll(target); err != nil {
+			color.Red("Failed to remove %s: %v", target, err)
+			os.Exit(1)
+		}
+		fmt.Printf(" %s %s %s\n", color.HiGreenString("✓"), target, color.RedString("removed"))
+	}
+}
+
+func handleLockfileMerge() {
+	if len(os.Args) < 5 {
+		color.Red("Usage: gpm lockfile-merge <base> <ours> <theirs>")
+		os.Exit(1)
+	}
+
+	var lockFiles []*LockFile
+	for _, path := range os.Args[2:5] {
+		lockFile, err := readLockFile(path)
+		if err != nil {
+			color.Red("Failed to read %s: %v", path, err)
+			os.Exit(1)
+		}
+		lockFiles = append(lockFiles, lockFile)
+	}
+
+	merged := mergeLockFiles(lockFiles[0], lockFiles[1], lockFiles[2])
+
+	if err := merged.writeLockFile(os.Args[3]); err != nil {
+		color.Red("Failed to write merged lockfile: %v", err)
+		os.Exit(1)
+	}
+}
+
+func handleInfo() {
+	showSize := false
+	top := 0
+
+	for i := 2; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		value := ""
+		if arg == "--size" {
+			showSize = true
+		} else if strings.HasPrefix(arg, "--top=") {
+			value = strings.TrimPrefix(arg, "--top=")
+		} else if arg == "--top" && i+1 < len(os.Args) {
+			value = os.Args[i+1]
+			i++
+		}
+
+		if value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				color.Red("Invalid --top value: %s (expected a positive number)", value)
+				os.Exit(1)
+			}
+			top = n
+		}
+	}
+
+	if !showSize {
+		color.Red("Usage: gpm info --size [--top N]")
+		os.Exit(1)
+	}
+
+	pkg, err := loadPackageJSON("package.json")
+	if err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+
+	var names []string
+	names = append(names, sortedKeys(pkg.Dependencies)...)
+	names = append(names, sortedKeys(pkg.DevDependencies)...)
+	names = append(names, sortedKeys(pkg.OptionalDependencies)...)
+
+	nodeModulesPath := nodeModulesDir()
+	sizes, err := measureDependencySizes(nodeModulesPath, names)
+	if err != nil {
+		color.Red("Failed to measure node_modules: %v", err)
+		os.Exit(1)
+	}
+
+	totalSize, err := (&sizeWalker{nodeModulesPath: nodeModulesPath, sizes: make(map[string]int64)}).dirSize(nodeModulesPath)
+	if err != nil && fileExists(nodeModulesPath) {
+		color.Red("Failed to measure node_modules: %v", err)
+		os.Exit(1)
+	}
+
+	printPackageSizes(sizes, top, totalSize)
+}
+
+func handleBin() {
+	bm := NewBinaryManager()
+	binaries, err := bm.listBinaries()
+	if err != nil {
+		color.Red("Failed to list binaries: %v", err)
+		os.Exit(1)
+	}
+
+	if len(binaries) == 0 {
+		fmt.Printf("\n %s No binaries found\n", color.HiBlackString("ℹ"))
+		return
+	}
+
+	fmt.Printf("\n %s Available binaries (%d)\n", color.CyanString("🔧"), len(binaries))
+	for _, binary := range binaries {
+		fmt.Printf("   %s\n", color.CyanString(binary))
+	}
 	fmt.Println()
 }
 
+func handleExec() {
+	if len(os.Args) < 3 {
+		color.Red("Usage: gpm exec <binary> [args...]")
+		os.Exit(1)
+	}
+	name := os.Args[2]
+
+	bm := NewBinaryManager()
+	binaries, err := bm.listBinaries()
+	if err != nil {
+		color.Red("Failed to list binaries: %v", err)
+		os.Exit(1)
+	}
+
+	if !slices.Contains(binaries, name) {
+		color.Red("Binary %s is not installed in %s", name, bm.binPath)
+		if suggestion := closestMatch(name, binaries); suggestion != "" {
+			fmt.Printf(" %s Did you mean %s?\n", color.HiBlackString("ℹ"), color.CyanString(suggestion))
+		}
+		os.Exit(1)
+	}
+
+	code, err := runBinary(bm.binaryCommand(name, execArgs))
+	if err != nil {
+		color.Red("Failed to run %s: %v", name, err)
+	}
+	os.Exit(code)
+}
+
 func handleCache() {
 	if len(os.Args) < 3 {
 		printCacheUsage()
@@ -273,9 +1388,15 @@ func handleCache() {
 	case "info":
 		showCacheInfo(cache)
 	case "clear":
-		clearCache(cache)
+		if len(os.Args) > 3 {
+			clearCachedPackages(cache, os.Args[3:])
+		} else {
+			clearCache(cache)
+		}
 	case "ls", "list":
 		listCache(cache)
+	case "verify":
+		verifyCache(cache, hasFlag("--prune"))
 	default:
 		color.Red("Unknown cache command: %s", subcommand)
 		printCacheUsage()
@@ -305,14 +1426,56 @@ func showCacheInfo(cache *Cache) {
 func clearCache(cache *Cache) {
 	fmt.Printf(" %s Clearing cache...", color.YellowString("⚡"))
 	if err := cache.clear(); err != nil {
-		fmt.Print("\r                                        \r")
+		clearLine()
 		color.Red("Failed to clear cache: %v", err)
 		os.Exit(1)
 	}
-	fmt.Print("\r                                        \r")
+	clearLine()
 	fmt.Printf(" %s Cache cleared successfully!\n", color.HiGreenString("✓"))
 }
 
+func clearCachedPackages(cache *Cache, specs []string) {
+	var freed int64
+	removed := 0
+
+	for _, spec := range specs {
+		name, version := parsePackageSpec(spec)
+
+		versions := []string{version}
+		if version == "latest" {
+			var err error
+			versions, err = cache.cachedVersions(name)
+			if err != nil {
+				color.Red("Failed to read cache for %s: %v", name, err)
+				os.Exit(1)
+			}
+		}
+
+		found := false
+		for _, v := range versions {
+			if !cache.hasPackage(name, v) {
+				continue
+			}
+			found = true
+
+			size, err := cache.removePackage(name, v)
+			freed += size
+			if err != nil {
+				color.Red("Failed to remove %s@%s from cache: %v", name, v, err)
+				os.Exit(1)
+			}
+			removed++
+			fmt.Printf(" %s %s@%s %s\n", color.RedString("✗"), color.CyanString(name), color.HiBlackString(v), color.HiBlackString("(%s)", formatBytes(size)))
+		}
+
+		if !found {
+			fmt.Printf(" %s %s is not cached\n", color.YellowString("⚠"), color.CyanString(spec))
+		}
+	}
+
+	fmt.Printf(" %s Removed %d cache entry(s), freed %s\n", color.HiGreenString("✓"), removed, formatBytes(freed))
+}
+
 func listCache(cache *Cache) {
 	packages, err := cache.listPackages()
 	if err != nil {
@@ -331,13 +1494,123 @@ func listCache(cache *Cache) {
 	}
 }
 
+func verifyCache(cache *Cache, prune bool) {
+	sizeBefore, err := cache.getCacheSize()
+	if err != nil {
+		color.Red("Failed to get cache info: %v", err)
+		os.Exit(1)
+	}
+
+	checked, problems, err := cache.verify()
+	if err != nil {
+		color.Red("Failed to verify cache: %v", err)
+		os.Exit(1)
+	}
+
+	for _, problem := range problems {
+		fmt.Printf("   %s %s %s\n", color.RedString("✗"), color.CyanString(problem.Label), color.HiBlackString("(%s)", problem.Problem))
+	}
+
+	if len(problems) == 0 {
+		fmt.Printf(" %s Checked %d cache entry(s), all valid\n", color.HiGreenString("✓"), checked)
+		return
+	}
+
+	if !prune {
+		fmt.Printf(" %s Checked %d cache entry(s), found %d problem(s)\n", color.RedString("✗"), checked, len(problems))
+		fmt.Printf(" %s Run gpm cache verify --prune to remove them\n", color.HiBlackString("ℹ"))
+		os.Exit(1)
+	}
+
+	removed := 0
+	for _, problem := range problems {
+		if err := problem.remove(); err != nil {
+			color.Red("Failed to remove %s: %v", problem.Label, err)
+			continue
+		}
+		removed++
+	}
+
+	sizeAfter, err := cache.getCacheSize()
+	if err != nil {
+		sizeAfter = sizeBefore
+	}
+	fmt.Printf(" %s Checked %d cache entry(s), fixed %d of %d problem(s), reclaimed %s\n", color.HiGreenString("✓"), checked, removed, len(problems), formatBytes(max(sizeBefore-sizeAfter, 0)))
+	if removed < len(problems) {
+		os.Exit(1)
+	}
+}
+
+func handleStore() {
+	var args []string
+	for _, arg := range os.Args[2:] {
+		if !strings.HasPrefix(arg, "--") {
+			args = append(args, arg)
+		}
+	}
+
+	if len(args) != 2 || args[0] != "path" {
+		color.Red("Usage: gpm store path <package>@<version> [--verify]")
+		os.Exit(1)
+	}
+
+	name, version := parsePackageSpec(args[1])
+	version, err := validateVersion(version)
+	if err != nil {
+		color.Red("Please give an exact version, e.g. %s@1.2.3", name)
+		os.Exit(1)
+	}
+
+	pm := NewPackageManager()
+	entry := inspectStoreEntry(pm.cache, name, version)
+
+	fmt.Printf("\n %s %s@%s\n", color.CyanString("📦"), color.CyanString(entry.Name), color.HiBlackString(entry.Version))
+	fmt.Printf(" Path: %s\n", color.HiBlackString(entry.Path))
+
+	switch {
+	case !entry.Present:
+		fmt.Printf(" Status: %s\n", color.YellowString("not cached"))
+	case entry.Problem != "":
+		fmt.Printf(" Status: %s\n", color.RedString("invalid (%s)", entry.Problem))
+	default:
+		fmt.Printf(" Status: %s\n", color.GreenString("present"))
+	}
+	if entry.Integrity != "" {
+		fmt.Printf(" Integrity: %s\n", color.WhiteString(entry.Integrity))
+	}
+
+	ok := entry.Present && entry.Problem == ""
+
+	if hasFlag("--verify") && entry.Present {
+		lockFile, err := loadLockFile()
+		if err != nil {
+			color.Red("Failed to load lockfile: %v", err)
+			os.Exit(1)
+		}
+
+		source, err := verifyStoreEntry(pm, lockFile, entry)
+		if err != nil {
+			fmt.Printf(" %s Integrity check failed: %v\n", color.RedString("✗"), err)
+			ok = false
+		} else {
+			fmt.Printf(" %s Integrity matches %s\n", color.HiGreenString("✓"), source)
+		}
+	}
+
+	if !ok {
+		os.Exit(1)
+	}
+}
+
 func printCacheUsage() {
 	fmt.Printf("\n%s GPM Cache Commands\n\n", color.CyanString("⚡"))
 	fmt.Println("Usage:")
 	fmt.Println("  gpm cache info               Show cache information")
 	fmt.Println("  gpm cache clear              Clear the cache")
+	fmt.Println("  gpm cache clear <pkg>[@ver]  Remove one package (or version) from the cache")
 	fmt.Println("  gpm cache ls                 List cached packages")
 	fmt.Println("  gpm cache list               List cached packages")
+	fmt.Println("  gpm cache verify [--prune]   Check cached packages and remove corrupt entries")
 	fmt.Println()
 }
 
@@ -361,11 +1634,69 @@ func printUsage() {
 	fmt.Println("  gpm install <package>        Install a package")
 	fmt.Println("  gpm i <package>              Install a package (short)")
 	fmt.Println("  gpm install <pkg> --save-dev Install as dev dependency")
+	fmt.Println("  gpm install <pkg> --no-save  Install without writing package.json (default with save=false; --save overrides)")
 	fmt.Println("  gpm uninstall <package>      Uninstall a package")
+	fmt.Println("  gpm uninstall <pkg> --save-dev  Remove only from devDependencies (--save-prod for dependencies)")
 	fmt.Println("  gpm upgrade [package]        Upgrade packages to latest")
 	fmt.Println("  gpm upgrade --all            Upgrade all packages without prompt")
+	fmt.Println("  gpm upgrade --latest         Also upgrade pinned (exact) dependencies")
+	fmt.Println("  gpm upgrade --dry-run [--json]  Show what would be upgraded")
+	fmt.Println("  gpm update-lock              Refresh locked versions within package.json ranges")
+	fmt.Println("  gpm verify [--integrity]     Check node_modules matches the lockfile exactly")
+	fmt.Println("  gpm outdated [--json]        Show current, wanted and latest versions; exits 1 if anything is outdated")
+	fmt.Println("  gpm outdated --exit-code=SEV Only exit 1 for drift of at least patch, minor or major")
+	fmt.Println("  gpm outdated --depth[=N]     Include transitive packages from the lockfile")
+	fmt.Println("  gpm audit [--audit-level=X]  Check installed packages for vulnerabilities")
+	fmt.Println("  gpm audit --audit-registry URL  Query a custom npm-compatible advisory endpoint")
+	fmt.Println("  gpm install --audit          Install and then run an audit")
+	fmt.Println("  gpm install --audit-fix [--allow-major]  Upgrade vulnerable packages to safe versions")
+	fmt.Println("  gpm install --verify-tree    Check every dependency is present afterwards")
+	fmt.Println("  gpm install --strict         Fail when the dependency tree is incomplete")
+	fmt.Println("  gpm install --reporter=NAME  Output style: default, silent, json, ndjson")
+	fmt.Println("  gpm install --frozen         Install exactly what the lockfile records")
+	fmt.Println("  gpm install --frozen-lockfile  Fail if package.json and the lockfile disagree; never write either")
+	fmt.Println("  gpm install --offline        Install only from the lockfile and cache; never use the network")
+	fmt.Println("  gpm install --prefer-offline Use cached metadata and packages, fetching only what is missing")
+	fmt.Println("  gpm install --fix-lockfile   Regenerate a corrupt lockfile from node_modules")
+	fmt.Println("  gpm <command> --no-lockfile  Ignore the lockfile and re-resolve")
+	fmt.Println("  gpm <command> --ignore-scripts  Skip pre/post install and upgrade scripts")
+	fmt.Println("  gpm install --no-progress    Disable spinners, progress bars and timers")
+	fmt.Println("  gpm install --check-files    Reinstall packages with missing files")
+	fmt.Println("  gpm install --only-missing   Install only packages absent from node_modules")
+	fmt.Println("  gpm install --timings[=N]    Show the N slowest packages after installing (default 10)")
+	fmt.Println("  gpm install --before DATE    Resolve only versions published before DATE")
+	fmt.Println("  gpm install --install-peers  Install missing peer dependencies instead of only warning")
+	fmt.Println("  gpm install --dedupe-report  Show what deduping would collapse and save, without changing anything")
+	fmt.Println("  gpm install --clean          Remove a node_modules created by npm, yarn or pnpm before installing")
+	fmt.Println("  gpm install --no-verify      Skip checking downloads against the registry's shasum/integrity")
+	fmt.Println("  gpm install --force          Reinstall even if node_modules is already up to date")
+	fmt.Println("  gpm install --deps-of <pkg>  Reinstall the dependency tree of an installed package")
+	fmt.Println("  gpm install --strict-ranges  Refuse packages given without a version or range")
+	fmt.Println("  gpm install --engine-strict  Fail on packages whose engines.node excludes the active node")
+	fmt.Println("  gpm install --ignore-engines Skip engines.node checks entirely")
+	fmt.Println("  gpm <command> --prefix DIR   Keep node_modules and the lockfile in DIR")
+	fmt.Println("  gpm install --manifest FILE  Read dependencies from FILE instead of package.json")
+	fmt.Println("  gpm install --max-rate 2MB/s Cap total download bandwidth")
+	fmt.Println("  gpm install --fetch-timeout 30s --download-timeout 5m  Override network timeouts")
+	fmt.Println("  gpm install --concurrency N  Install N packages at a time (default: CPU count within 4-16, max 64; also GPM_CONCURRENCY)")
+	fmt.Println("                               Workers share one HTTP connection pool per registry host")
+	fmt.Println("  gpm install --fetch-attempts N  Retry network errors, 5xx and 429 responses up to N attempts (default 3)")
+	fmt.Println("  gpm install --progress-json  Stream progress as JSON lines on stderr")
+	fmt.Println("  gpm version                  Print the gpm version")
+	fmt.Println("  gpm ls [--json]              Show the installed dependency tree (alias: list)")
+	fmt.Println("  gpm ls --depth=N             Limit the tree to N levels below the top-level packages")
+	fmt.Println("  gpm tree --duplicates [--json]  List packages installed at more than one version")
+	fmt.Println("  gpm migrate [--yes] [--force]   Import an npm/yarn/pnpm lockfile and reinstall with gpm")
+	fmt.Println("  gpm graph [--format=dot|json]   Export the lockfile dependency graph (GraphViz DOT or adjacency list)")
+	fmt.Println("  gpm clean [--lock] [--yes]   Remove node_modules (and the lockfile)")
+	fmt.Println("  gpm lockfile-merge <base> <ours> <theirs>  Git merge driver for the lockfile")
+	fmt.Println("  gpm info --size [--top N]    Show the disk footprint of each dependency")
+	fmt.Println("  gpm add-script <name> <cmd>  Add or update a package.json script")
+	fmt.Println("  gpm remove-script <name>     Remove a package.json script")
 	fmt.Println("  gpm bin                      List available binaries")
+	fmt.Println("  gpm exec <bin> [args...]     Run a binary from node_modules/.bin")
 	fmt.Println("  gpm cache <command>          Cache management")
+	fmt.Println("  gpm store path <pkg>@<ver> [--verify]  Show (and check) a cache entry")
 	fmt.Println("  gpm help                     Show this help message")
 	fmt.Println("\nExamples:")
 	fmt.Printf("  gpm install                  %s Install from package.json\n", color.GreenString("✓"))
@@ -375,11 +1706,76 @@ func printUsage() {
 	fmt.Printf("  gpm uninstall lodash         %s Remove lodash\n", color.RedString("✗"))
 	fmt.Printf("  gpm upgrade                  %s Upgrade packages (interactive)\n", color.BlueString("⬆"))
 	fmt.Printf("  gpm upgrade --all            %s Upgrade all packages\n", color.BlueString("⬆"))
+	fmt.Printf("  gpm outdated --exit-code=major %s Fail CI on major drift\n", color.YellowString("!"))
 	fmt.Printf("  gpm bin                      %s List available binaries\n", color.CyanString("🔧"))
 	fmt.Printf("  gpm cache info               %s Show cache info\n", color.CyanString("ℹ"))
 	fmt.Println("\nNote: Requires package.json in current directory")
 }
 
+func hasFlag(name string) bool {
+	for _, arg := range os.Args[1:] {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTimeoutFlag(value string) time.Duration {
+	timeout, err := parseDuration(value)
+	if err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+	return timeout
+}
+
+func parseCountFlag(name, value string) int {
+	count, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || count < 1 {
+		color.Red("Invalid %s: %s (expected a positive number)", name, value)
+		os.Exit(1)
+	}
+	return count
+}
+
+func parseDateFlag(value string) time.Time {
+	date, err := parseDate(value)
+	if err != nil {
+		color.Red("%v", err)
+		os.Exit(1)
+	}
+	return date
+}
+
+func takeFlagValue(name string) string {
+	for i := 2; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		if strings.HasPrefix(arg, name+"=") {
+			os.Args = append(os.Args[:i], os.Args[i+1:]...)
+			return strings.TrimPrefix(arg, name+"=")
+		}
+		if arg == name && i+1 < len(os.Args) {
+			value := os.Args[i+1]
+			os.Args = append(os.Args[:i], os.Args[i+2:]...)
+			return value
+		}
+	}
+	return ""
+}
+
+func flagValue(name string) string {
+	for i, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, name+"=") {
+			return strings.TrimPrefix(arg, name+"=")
+		}
+		if arg == name && i+2 < len(os.Args) {
+			return os.Args[i+2]
+		}
+	}
+	return ""
+}
+
 func fileExists(filename string) bool {
 	_, err := os.Stat(filename)
 	return !os.IsNotExist(err)
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+var dependencySections = []string{"dependencies", "devDependencies", "optionalDependencies"}
+
+func checkManifestDependencies(pkg *PackageJSON, data []byte) ([]string, error) {
+	if pkg.Name != "" {
+		if _, ok := pkg.Dependencies[pkg.Name]; ok {
+			return nil, fmt.Errorf("%s depends on itself in dependencies", pkg.Name)
+		}
+		if _, ok := pkg.DevDependencies[pkg.Name]; ok {
+			return nil, fmt.Errorf("%s depends on itself in devDependencies", pkg.Name)
+		}
+		if _, ok := pkg.OptionalDependencies[pkg.Name]; ok {
+			return nil, fmt.Errorf("%s depends on itself in optionalDependencies", pkg.Name)
+		}
+	}
+
+	for name := range pkg.OptionalDependencies {
+		delete(pkg.Dependencies, name)
+	}
+
+	var warnings []string
+
+	for _, key := range duplicateDependencyKeys(data) {
+		warnings = append(warnings, fmt.Sprintf("%s is declared more than once; only the last entry is used", key))
+	}
+
+	var shared []string
+	for name := range pkg.DevDependencies {
+		if _, ok := pkg.Dependencies[name]; ok {
+			shared = append(shared, name)
+		}
+	}
+	sort.Strings(shared)
+	for _, name := range shared {
+		warnings = append(warnings, fmt.Sprintf("%s is in both dependencies and devDependencies; installing %s from dependencies", name, pkg.Dependencies[name]))
+		delete(pkg.DevDependencies, name)
+	}
+
+	return warnings, nil
+}
+
+func duplicateDependencyKeys(data []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	var duplicates []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return duplicates
+		}
+		section, _ := token.(string)
+
+		isDependencySection := false
+		for _, name := range dependencySections {
+			if section == name {
+				isDependencySection = true
+			}
+		}
+
+		if !isDependencySection {
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return duplicates
+			}
+			continue
+		}
+
+		if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+			return duplicates
+		}
+
+		seen := make(map[string]bool)
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return duplicates
+			}
+			name, _ := token.(string)
+			if seen[name] {
+				duplicates = append(duplicates, fmt.Sprintf("%s in %s", name, section))
+			}
+			seen[name] = true
+
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return duplicates
+			}
+		}
+		if _, err := decoder.Token(); err != nil {
+			return duplicates
+		}
+	}
+
+	return duplicates
+}
+
+func parseManifest(data []byte, pkg *PackageJSON) error {
+	if err := malformedDependencyEntry(data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, pkg)
+}
+
+func malformedDependencyEntry(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+		section, _ := token.(string)
+
+		isDependencySection := false
+		for _, name := range dependencySections {
+			if section == name {
+				isDependencySection = true
+			}
+		}
+
+		offset := decoder.InputOffset()
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil
+		}
+		if !isDependencySection {
+			continue
+		}
+
+		var entries map[string]json.RawMessage
+		if err := json.Unmarshal(value, &entries); err != nil {
+			return fmt.Errorf("%s must be an object, got %s (line %d)", section, jsonKind(value), lineAt(data, offset))
+		}
+
+		entryDecoder := json.NewDecoder(bytes.NewReader(value))
+		entryDecoder.Token()
+		for entryDecoder.More() {
+			token, err := entryDecoder.Token()
+			if err != nil {
+				break
+			}
+			name, _ := token.(string)
+
+			entryOffset := entryDecoder.InputOffset()
+			var entry json.RawMessage
+			if err := entryDecoder.Decode(&entry); err != nil {
+				break
+			}
+
+			var version string
+			if err := json.Unmarshal(entry, &version); err != nil || jsonKind(entry) == "null" {
+				return fmt.Errorf("%s[%q] must be a version string, got %s (line %d)", section, name, jsonKind(entry), lineAt(data, offset+entryOffset))
+			}
+		}
+	}
+
+	return nil
+}
+
+func jsonKind(value json.RawMessage) string {
+	trimmed := bytes.TrimSpace(value)
+	if len(trimmed) == 0 {
+		return "nothing"
+	}
+
+	switch trimmed[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+	return "number " + string(trimmed)
+}
+
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
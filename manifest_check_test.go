@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckManifestDependenciesRejectsSelfDependency(t *testing.T) {
+	for _, section := range dependencySections {
+		data := []byte(`{"name": "my-app", "` + section + `": {"my-app": "^1.0.0"}}`)
+		var pkg PackageJSON
+		if err := parseManifest(data, &pkg); err != nil {
+			t.Fatal(err)
+		}
+		_, err := checkManifestDependencies(&pkg, data)
+		if err == nil || !strings.Contains(err.Error(), "my-app depends on itself in "+section) {
+			t.Errorf("self-dependency in %s: got %v", section, err)
+		}
+	}
+
+	data := []byte(`{"name": "my-app", "dependencies": {"lodash": "^4.17.21"}}`)
+	var pkg PackageJSON
+	if err := parseManifest(data, &pkg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkManifestDependencies(&pkg, data); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDuplicateDependencyKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"no duplicates", `{"dependencies": {"lodash": "^4.17.21", "ms": "^2.1.3"}}`, nil},
+		{"repeated in dependencies", `{"dependencies": {"lodash": "^3.0.0", "ms": "^2.1.3", "lodash": "^4.17.21"}}`, []string{"lodash in dependencies"}},
+		{"repeated in two sections", `{"devDependencies": {"jest": "29", "jest": "30"}, "optionalDependencies": {"fsevents": "2", "fsevents": "2"}}`, []string{"jest in devDependencies", "fsevents in optionalDependencies"}},
+		{"same name across sections", `{"dependencies": {"ms": "^2.1.3"}, "devDependencies": {"ms": "^2.1.3"}}`, nil},
+		{"other sections ignored", `{"scripts": {"test": "jest", "test": "vitest"}}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := duplicateDependencyKeys([]byte(tt.data)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("duplicateDependencyKeys = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckManifestDependenciesWarnings(t *testing.T) {
+	data := []byte(`{
+  "name": "my-app",
+  "dependencies": {"lodash": "^3.0.0", "react": "^18.2.0", "lodash": "^4.17.21"},
+  "devDependencies": {"react": "^17.0.0", "jest": "^29.0.0"}
+}`)
+	var pkg PackageJSON
+	if err := parseManifest(data, &pkg); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings, err := checkManifestDependencies(&pkg, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantWarnings := []string{
+		"lodash in dependencies is declared more than once; only the last entry is used",
+		"react is in both dependencies and devDependencies; installing ^18.2.0 from dependencies",
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("warnings = %q, want %q", warnings, wantWarnings)
+	}
+
+	if want := map[string]string{"lodash": "^4.17.21", "react": "^18.2.0"}; !reflect.DeepEqual(pkg.Dependencies, want) {
+		t.Errorf("Dependencies = %v, want %v", pkg.Dependencies, want)
+	}
+	if want := map[string]string{"jest": "^29.0.0"}; !reflect.DeepEqual(pkg.DevDependencies, want) {
+		t.Errorf("DevDependencies = %v, want %v", pkg.DevDependencies, want)
+	}
+}
+
+func TestMalformedDependencyEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"valid", "{\n  \"dependencies\": {\"lodash\": \"^4.17.21\"}\n}", ""},
+		{"number version", "{\n  \"name\": \"app\",\n  \"dependencies\": {\n    \"lodash\": 4\n  }\n}", `dependencies["lodash"] must be a version string, got number 4 (line 4)`},
+		{"object version", "{\n  \"devDependencies\": {\"jest\": {\"version\": \"29\"}}\n}", `devDependencies["jest"] must be a version string, got object (line 2)`},
+		{"null version", "{\n  \"dependencies\": {\n    \"lodash\": null\n  }\n}", `dependencies["lodash"] must be a version string, got null (line 3)`},
+		{"array section", "{\n  \"name\": \"app\",\n  \"optionalDependencies\": [\"fsevents\"]\n}", "optionalDependencies must be an object, got array (line 3)"},
+		{"other sections ignored", `{"scripts": {"test": 1}}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := malformedDependencyEntry([]byte(tt.data))
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.want {
+				t.Errorf("malformedDependencyEntry = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
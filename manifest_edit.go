@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type jsonEntry struct {
+	Key        string
+	KeyStart   int
+	ValueStart int
+	ValueEnd   int
+	Value      json.RawMessage
+}
+
+type jsonObject struct {
+	Open    int
+	Close   int
+	Entries []jsonEntry
+}
+
+func scanJSONObject(data []byte, start int) (*jsonObject, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data[start:]))
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	object := &jsonObject{Open: start + int(decoder.InputOffset()) - 1}
+	for decoder.More() {
+		before := start + int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		end := start + int(decoder.InputOffset())
+
+		object.Entries = append(object.Entries, jsonEntry{
+			Key:        key,
+			KeyStart:   before + bytes.IndexByte(data[before:], '"'),
+			ValueStart: end - len(value),
+			ValueEnd:   end,
+			Value:      value,
+		})
+	}
+
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, err
+	}
+	object.Close = start + int(decoder.InputOffset()) - 1
+	return object, nil
+}
+
+func (o *jsonObject) find(key string) int {
+	for i, entry := range o.Entries {
+		if entry.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func encodeJSONString(value string) []byte {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+func lineIndent(data []byte, offset int) string {
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	indent := data[lineStart:offset]
+	return string(indent[:len(indent)-len(bytes.TrimLeft(indent, " \t"))])
+}
+
+func manifestIndentUnit(data []byte, root *jsonObject) string {
+	if len(root.Entries) > 0 {
+		if indent := lineIndent(data, root.Entries[0].KeyStart); indent != "" {
+			return indent
+		}
+	}
+	return "  "
+}
+
+func splice(data []byte, start, end int, insert string) []byte {
+	result := make([]byte, 0, len(data)-(end-start)+len(insert))
+	result = append(result, data[:start]...)
+	result = append(result, insert...)
+	return append(result, data[end:]...)
+}
+
+func insertJSONEntry(data []byte, object *jsonObject, indent, closeIndent, key string, value []byte) []byte {
+	entry := string(encodeJSONString(key)) + ": " + string(value)
+
+	if len(object.Entries) == 0 {
+		return splice(data, object.Open+1, object.Close, "\n"+indent+entry+"\n"+closeIndent)
+	}
+
+	last := object.Entries[len(object.Entries)-1]
+	return splice(data, last.ValueEnd, last.ValueEnd, ",\n"+lineIndent(data, last.KeyStart)+entry)
+}
+
+func setManifestEntry(data []byte, section, key, value string) ([]byte, error) {
+	root, err := scanJSONObject(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	unit := manifestIndentUnit(data, root)
+	encoded := encodeJSONString(value)
+
+	sectionIndex := root.find(section)
+	if sectionIndex < 0 {
+		sectionValue := "{\n" + unit + unit + string(encodeJSONString(key)) + ": " + string(encoded) + "\n" + unit + "}"
+		return insertJSONEntry(data, root, unit, "", section, []byte(sectionValue)), nil
+	}
+
+	sectionEntry := root.Entries[sectionIndex]
+	object, err := scanJSONObject(data, sectionEntry.ValueStart)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an object", section)
+	}
+
+	if i := object.find(key); i >= 0 {
+		entry := object.Entries[i]
+		return splice(data, entry.ValueStart, entry.ValueEnd, string(encoded)), nil
+	}
+
+	sectionIndent := lineIndent(data, sectionEntry.KeyStart)
+	return insertJSONEntry(data, object, sectionIndent+unit, sectionIndent, key, encoded), nil
+}
+
+func removeManifestEntry(data []byte, section, key string) ([]byte, bool, error) {
+	root, err := scanJSONObject(data, 0)
+	if err != nil {
+		return nil, false, err
+	}
+
+	sectionIndex := root.find(section)
+	if sectionIndex < 0 {
+		return data, false, nil
+	}
+
+	object, err := scanJSONObject(data, root.Entries[sectionIndex].ValueStart)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s must be an object", section)
+	}
+
+	i := object.find(key)
+	if i < 0 {
+		return data, false, nil
+	}
+
+	switch {
+	case len(object.Entries) == 1:
+		return splice(data, object.Open+1, object.Close, ""), true, nil
+	case i+1 < len(object.Entries):
+		return splice(data, object.Entries[i].KeyStart, object.Entries[i+1].KeyStart, ""), true, nil
+	default:
+		return splice(data, object.Entries[i-1].ValueEnd, object.Entries[i].ValueEnd, ""), true, nil
+	}
+}
+
+func editManifestFile(path string, edit func([]byte) ([]byte, error)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	updated, err := edit(data)
+	if err != nil {
+		return fmt.Errorf("failed to edit %s: %v", path, err)
+	}
+	if bytes.Equal(updated, data) {
+		return nil
+	}
+
+	if !json.Valid(updated) {
+		return fmt.Errorf("failed to edit %s: result is not valid JSON", path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, updated, info.Mode().Perm())
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const metadataCacheDirName = "_metadata"
+
+type metadataCache struct {
+	mu      sync.Mutex
+	entries map[string]*metadataEntry
+	dir     string
+}
+
+type metadataEntry struct {
+	ready chan struct{}
+	path  string
+	data  []byte
+	err   error
+}
+
+func (entry *metadataEntry) open() (io.ReadCloser, error) {
+	if entry.path == "" {
+		return io.NopCloser(bytes.NewReader(entry.data)), nil
+	}
+	return os.Open(entry.path)
+}
+
+func newMetadataCache(dir string) *metadataCache {
+	return &metadataCache{entries: make(map[string]*metadataEntry), dir: dir}
+}
+
+func (mc *metadataCache) enabled() bool {
+	return mc.dir != ""
+}
+
+func (mc *metadataCache) get(key string, fetch func(entry *metadataEntry) error) (*metadataEntry, error) {
+	mc.mu.Lock()
+	entry, ok := mc.entries[key]
+	if ok {
+		mc.mu.Unlock()
+		<-entry.ready
+		return entry, entry.err
+	}
+
+	entry = &metadataEntry{ready: make(chan struct{})}
+	mc.entries[key] = entry
+	mc.mu.Unlock()
+
+	entry.err = fetch(entry)
+	if entry.err != nil {
+		mc.mu.Lock()
+		delete(mc.entries, key)
+		mc.mu.Unlock()
+	}
+	close(entry.ready)
+	return entry, entry.err
+}
+
+func (mc *metadataCache) diskPath(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(mc.dir, hex.EncodeToString(hash[:16]))
+}
+
+func (mc *metadataCache) load(key string) (string, string) {
+	if !mc.enabled() {
+		return "", ""
+	}
+	path := mc.diskPath(key)
+	etag, err := os.ReadFile(path + ".etag")
+	if err != nil {
+		return "", ""
+	}
+	if _, err := os.Stat(path + ".json"); err != nil {
+		return "", ""
+	}
+	return strings.TrimSpace(string(etag)), path + ".json"
+}
+
+// spool stores a fetched document in entry. Without a metadata directory the
+// document is kept in memory so it is still fetched only once per run.
+func (mc *metadataCache) spool(entry *metadataEntry, key, etag string, body io.Reader) error {
+	if !mc.enabled() {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		entry.path, entry.data = "", data
+		return nil
+	}
+
+	if err := os.MkdirAll(mc.dir, 0755); err != nil {
+		return err
+	}
+	path := mc.diskPath(key)
+	if err := os.Remove(path + ".etag"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(mc.dir, ".metadata-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := io.Copy(tmpFile, body); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFile.Name(), path+".json"); err != nil {
+		return err
+	}
+
+	if etag != "" {
+		if err := writeFileAtomic(path+".etag", []byte(etag), 0644); err != nil {
+			return err
+		}
+	}
+	entry.path = path + ".json"
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type migrationDifference struct {
+	Name      string
+	Original  []string
+	Installed string
+}
+
+func migrationJobs(pkg *PackageJSON, lockFile *LockFile) ([]PackageJob, []gitDependency) {
+	var jobs []PackageJob
+	var gitDeps []gitDependency
+
+	groups := []struct {
+		deps     map[string]string
+		dev      bool
+		optional bool
+	}{
+		{pkg.Dependencies, false, false},
+		{pkg.DevDependencies, true, false},
+		{pkg.OptionalDependencies, false, true},
+	}
+	for _, group := range groups {
+		for _, name := range sortedKeys(group.deps) {
+			depRange := group.deps[name]
+			if isGitSpec(depRange) {
+				gitDeps = append(gitDeps, gitDependency{Name: name, Spec: depRange, IsDev: group.dev})
+				continue
+			}
+
+			version := depRange
+			if locked := lockFile.getPackageVersion(name); locked != "" {
+				version = locked
+				if realName, _, ok := parseAliasVersion(depRange); ok {
+					version = fmt.Sprintf("npm:%s@%s", realName, locked)
+				}
+			}
+
+			job := newPackageJob(name, version, group.dev, name+"@"+depRange)
+			job.Optional = group.optional
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, gitDeps
+}
+
+func installMigratedProject(pm *PackageManager, lockFile *LockFile, pkg *PackageJSON) error {
+	timer := NewTimer()
+	timer.Start()
+
+	jobs, gitDeps := migrationJobs(pkg, lockFile)
+	installErr := NewParallelInstaller(pm, lockFile, timer).InstallPackages(jobs, false)
+	if _, partial := installErr.(*partialInstallError); installErr != nil && !partial {
+		return installErr
+	}
+
+	for _, dep := range gitDeps {
+		if err := installGitDependency(pm, lockFile, dep.Name, dep.Spec, dep.IsDev); err != nil {
+			return fmt.Errorf("failed to install %s from %s: %v", dep.Name, dep.Spec, err)
+		}
+	}
+	pm.reportDependencyFailures()
+
+	if err := lockFile.saveLockFile(); err != nil {
+		return fmt.Errorf("failed to save lockfile: %v", err)
+	}
+	if len(gitDeps) > 0 {
+		if err := NewBinaryManager().setupAllBinaries(); err != nil {
+			reportWarning("Failed to setup some binaries: %v", err)
+		}
+	}
+
+	elapsed := timer.Stop()
+	reporter.Report(InstallEvent{Type: "done", ElapsedMs: elapsed.Milliseconds()})
+	if installErr == nil && pm.hasDependencyFailures() {
+		return fmt.Errorf("some transitive dependencies failed to install")
+	}
+	return installErr
+}
+
+func findMigrationDifferences(nodeModulesPath string, original map[string][]string) ([]migrationDifference, []string) {
+	names := make([]string, 0, len(original))
+	for name := range original {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var differences []migrationDifference
+	for _, name := range names {
+		versions := original[name]
+		installed := ""
+		if manifest, err := readInstalledManifest(filepath.Join(nodeModulesPath, name)); err == nil {
+			installed = manifest.Version
+		}
+		if len(versions) == 1 && versions[0] == installed {
+			continue
+		}
+		differences = append(differences, migrationDifference{Name: name, Original: versions, Installed: installed})
+	}
+
+	var added []string
+	installedPackages, _ := listInstalledPackages(nodeModulesPath)
+	for _, name := range installedPackages {
+		if _, ok := original[name]; ok {
+			continue
+		}
+		if manifest, err := readInstalledManifest(filepath.Join(nodeModulesPath, name)); err == nil {
+			added = append(added, fmt.Sprintf("%s@%s", name, manifest.Version))
+		}
+	}
+	sort.Strings(added)
+
+	return differences, added
+}
+
+func printMigrationDifferences(source string, differences []migrationDifference, added []string) {
+	if len(differences) == 0 && len(added) == 0 {
+		fmt.Printf(" %s Installed versions match %s\n", color.HiGreenString("✓"), source)
+		return
+	}
+
+	fmt.Printf(" %s %d resolution difference(s) from %s:\n", color.YellowString("⚠"), len(differences)+len(added), source)
+	for _, difference := range differences {
+		original := strings.Join(difference.Original, ", ")
+		switch {
+		case difference.Installed == "":
+			fmt.Printf("   %s %s %s\n", color.CyanString(difference.Name), original, color.RedString("not installed"))
+		case len(difference.Original) > 1 && containsVersion(difference.Original, difference.Installed):
+			fmt.Printf("   %s %s %s %s %s\n", color.CyanString(difference.Name), original, color.BlueString("→"),
+				color.GreenString(difference.Installed), color.HiBlackString("(gpm keeps one version per package)"))
+		default:
+			fmt.Printf("   %s %s %s %s\n", color.CyanString(difference.Name), original, color.BlueString("→"), color.GreenString(difference.Installed))
+		}
+	}
+	for _, name := range added {
+		fmt.Printf("   %s %s %s\n", color.GreenString("+"), color.CyanString(name), color.HiBlackString("(not in %s)", source))
+	}
+}
+
+func containsVersion(versions []string, version string) bool {
+	for _, candidate := range versions {
+		if candidate == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var installPrefix = "."
+
+func nodeModulesDir() string {
+	return filepath.Join(installPrefix, "node_modules")
+}
+
+type InstalledManifest struct {
+	Name                 string                        `json:"name"`
+	Version              string                        `json:"version"`
+	Dependencies         map[string]string             `json:"dependencies"`
+	OptionalDependencies map[string]string             `json:"optionalDependencies"`
+	PeerDependencies     map[string]string             `json:"peerDependencies"`
+	PeerDependenciesMeta map[string]PeerDependencyMeta `json:"peerDependenciesMeta"`
+}
+
+type PeerDependencyMeta struct {
+	Optional bool `json:"optional"`
+}
+
+func listInstalledPackages(nodeModulesPath string) ([]string, error) {
+	if !fileExists(nodeModulesPath) {
+		return []string{}, nil
+	}
+
+	entries, err := os.ReadDir(nodeModulesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		if strings.HasPrefix(entry.Name(), "@") {
+			scopeEntries, err := os.ReadDir(filepath.Join(nodeModulesPath, entry.Name()))
+			if err != nil {
+				continue
+			}
+			for _, scopeEntry := range scopeEntries {
+				if scopeEntry.IsDir() && !strings.HasPrefix(scopeEntry.Name(), ".") {
+					packages = append(packages, entry.Name()+"/"+scopeEntry.Name())
+				}
+			}
+			continue
+		}
+
+		packages = append(packages, entry.Name())
+	}
+
+	sort.Strings(packages)
+	return packages, nil
+}
+
+func readInstalledManifest(packagePath string) (*InstalledManifest, error) {
+	data, err := os.ReadFile(filepath.Join(packagePath, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest InstalledManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+func resolveInstalledDependency(nodeModulesPath, parentPath, depName string) string {
+	nested := filepath.Join(parentPath, "node_modules", depName)
+	if fileExists(filepath.Join(nested, "package.json")) {
+		return nested
+	}
+
+	hoisted := filepath.Join(nodeModulesPath, depName)
+	if fileExists(filepath.Join(hoisted, "package.json")) {
+		return hoisted
+	}
+
+	return ""
+}
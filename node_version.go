@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+var nodeVersionFiles = []string{".nvmrc", ".node-version"}
+
+var pinnedNodeBinDir string
+
+func pinnedNodeVersion() (string, string) {
+	for _, name := range nodeVersionFiles {
+		file, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			file.Close()
+			return strings.TrimPrefix(line, "v"), name
+		}
+		file.Close()
+	}
+	return "", ""
+}
+
+func activeNodeVersion() string {
+	node := "node"
+	if pinnedNodeBinDir != "" {
+		node = filepath.Join(pinnedNodeBinDir, nodeExecutable())
+	}
+
+	out, err := exec.Command(node, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), "v")
+}
+
+func nodeVersionMatches(pinned, version string) bool {
+	pinnedParts := strings.Split(pinned, ".")
+	versionParts := strings.Split(version, ".")
+	if len(pinnedParts) > len(versionParts) {
+		return false
+	}
+
+	for i, part := range pinnedParts {
+		if part != "x" && part != "*" && part != versionParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func isNodeVersionAlias(pinned string) bool {
+	return pinned == "node" || pinned == "stable" || strings.HasPrefix(pinned, "lts")
+}
+
+func checkPinnedNode() {
+	pinned, source := pinnedNodeVersion()
+	if pinned == "" || isNodeVersionAlias(pinned) {
+		return
+	}
+
+	active := activeNodeVersion()
+	if active != "" && nodeVersionMatches(pinned, active) {
+		return
+	}
+
+	if binDir, version := findManagedNode(pinned); binDir != "" {
+		pinnedNodeBinDir = binDir
+		reporter.Report(InstallEvent{Type: "info", Version: version, Message: fmt.Sprintf("Using Node v%s for scripts (%s pins %s)", version, source, pinned)})
+		return
+	}
+
+	if active == "" {
+		reportWarning("%s pins Node %s but node was not found on PATH", source, pinned)
+	} else {
+		reportWarning("%s pins Node %s but the active node is v%s", source, pinned, active)
+	}
+}
+
+func nodeManagerDirs() []string {
+	homeDir, _ := os.UserHomeDir()
+
+	nvmDir := os.Getenv("NVM_DIR")
+	if nvmDir == "" {
+		nvmDir = filepath.Join(homeDir, ".nvm")
+	}
+	fnmDir := os.Getenv("FNM_DIR")
+	if fnmDir == "" {
+		fnmDir = filepath.Join(homeDir, ".local", "share", "fnm")
+	}
+
+	return []string{
+		filepath.Join(nvmDir, "versions", "node"),
+		filepath.Join(fnmDir, "node-versions"),
+		filepath.Join(homeDir, ".volta", "tools", "image", "node"),
+	}
+}
+
+func findManagedNode(pinned string) (string, string) {
+	var bestDir, bestVersion string
+
+	for _, dir := range nodeManagerDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			version := strings.TrimPrefix(name, "v")
+			if !nodeVersionMatches(pinned, version) {
+				continue
+			}
+			if bestVersion != "" && compareVersions(version, bestVersion) <= 0 {
+				continue
+			}
+
+			for _, binDir := range []string{
+				filepath.Join(dir, name, "bin"),
+				filepath.Join(dir, name, "installation", "bin"),
+				filepath.Join(dir, name),
+			} {
+				if fileExists(filepath.Join(binDir, nodeExecutable())) {
+					bestDir, bestVersion = binDir, version
+					break
+				}
+			}
+		}
+	}
+
+	return bestDir, bestVersion
+}
+
+func nodeExecutable() string {
+	if runtime.GOOS == "windows" {
+		return "node.exe"
+	}
+	return "node"
+}
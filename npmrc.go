@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func isRegistryConfigKey(key string) bool {
+	switch {
+	case key == "registry":
+		return true
+	case strings.HasPrefix(key, "@") && strings.HasSuffix(key, ":registry"):
+		return true
+	case strings.HasPrefix(key, "//") && strings.HasSuffix(key, ":_authToken"):
+		return true
+	}
+	return false
+}
+
+func (c *Config) scopeRegistries() map[string]string {
+	registries := make(map[string]string)
+	for key, value := range c.values {
+		if strings.HasPrefix(key, "@") && strings.HasSuffix(key, ":registry") && value != "" {
+			registries[strings.TrimSuffix(key, ":registry")] = strings.TrimSuffix(value, "/")
+		}
+	}
+	return registries
+}
+
+func (c *Config) authTokens() map[string]string {
+	tokens := make(map[string]string)
+	for key, value := range c.values {
+		if strings.HasPrefix(key, "//") && strings.HasSuffix(key, ":_authToken") && value != "" {
+			prefix := strings.TrimSuffix(key, ":_authToken")
+			if !strings.HasSuffix(prefix, "/") {
+				prefix += "/"
+			}
+			tokens[prefix] = value
+		}
+	}
+	return tokens
+}
+
+func packageScope(packageName string) string {
+	if !strings.HasPrefix(packageName, "@") {
+		return ""
+	}
+	scope, _, _ := strings.Cut(packageName, "/")
+	return scope
+}
+
+func (pm *PackageManager) registriesFor(packageName string) []string {
+	if registry, ok := pm.scopeRegistries[packageScope(packageName)]; ok {
+		return []string{registry}
+	}
+	return pm.registries()
+}
+
+func (pm *PackageManager) authTokenFor(target string) string {
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+
+	path := "//" + parsed.Host + parsed.Path
+	var best, token string
+	for prefix, value := range pm.authTokens {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(best) {
+			best, token = prefix, value
+		}
+	}
+	return token
+}
+
+func (pm *PackageManager) newRegistryRequest(ctx context.Context, method, target string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if token := pm.authTokenFor(target); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import "fmt"
+
+func (pm *PackageManager) lockedPackageInfo(packageName, version string) (*PackageInfo, bool) {
+	if pm.offlineLock == nil {
+		return nil, false
+	}
+
+	lockPkg, ok := pm.offlineLock.getLockedPackage(packageName)
+	if !ok || lockPkg.Skipped {
+		return nil, false
+	}
+	if version != "latest" && version != lockPkg.Version && !satisfiesRange(lockPkg.Version, version) {
+		return nil, false
+	}
+	if !pm.offline && !pm.cache.hasPackage(packageName, lockPkg.Version) {
+		return nil, false
+	}
+
+	return &PackageInfo{
+		Name:         packageName,
+		Version:      lockPkg.Version,
+		Dependencies: lockPkg.Dependencies,
+		Dist:         DistInfo{Tarball: lockPkg.Resolved, Integrity: lockPkg.Integrity},
+	}, true
+}
+
+func (pm *PackageManager) offlineMetadata(packageName, cachedPath string) (string, error) {
+	if cachedPath == "" {
+		return "", fmt.Errorf("%s is not in %s and has no cached metadata; cannot resolve it with --offline", packageName, lockFileName())
+	}
+	return cachedPath, nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type OutdatedEntry struct {
+	Current  string   `json:"current"`
+	Wanted   string   `json:"wanted,omitempty"`
+	Latest   string   `json:"latest"`
+	Severity string   `json:"severity"`
+	Type     string   `json:"type"`
+	Range    string   `json:"range,omitempty"`
+	Pinned   bool     `json:"pinned,omitempty"`
+	Parents  []string `json:"parents,omitempty"`
+}
+
+type TransitivePackage struct {
+	Name    string
+	Version string
+	Parents []string
+}
+
+var severityRank = map[string]int{
+	"patch": 1,
+	"minor": 2,
+	"major": 3,
+}
+
+func upgradeSeverity(current, latest string) string {
+	currentParts := strings.Split(current, ".")
+	latestParts := strings.Split(latest, ".")
+
+	for i, name := range []string{"major", "minor", "patch"} {
+		var c, l int
+		if i < len(currentParts) {
+			c = parseVersionPart(currentParts[i])
+		}
+		if i < len(latestParts) {
+			l = parseVersionPart(latestParts[i])
+		}
+		if c != l {
+			return name
+		}
+	}
+
+	return "patch"
+}
+
+func collectOutdated(upgrades []UpgradeInfo) map[string]OutdatedEntry {
+	entries := make(map[string]OutdatedEntry)
+
+	for _, upgrade := range upgrades {
+		if !upgrade.NeedsUpgrade {
+			continue
+		}
+
+		depType := "dependencies"
+		if upgrade.IsDev {
+			depType = "devDependencies"
+		}
+
+		entries[upgrade.Name] = OutdatedEntry{
+			Current:  upgrade.CurrentVersion,
+			Wanted:   upgrade.WantedVersion,
+			Latest:   upgrade.LatestVersion,
+			Severity: upgradeSeverity(upgrade.CurrentVersion, upgrade.LatestVersion),
+			Type:     depType,
+			Range:    upgrade.DeclaredRange,
+			Pinned:   upgrade.Pinned,
+		}
+	}
+
+	return entries
+}
+
+func collectTransitive(lockFile *LockFile, maxDepth int) []TransitivePackage {
+	byName := make(map[string][]LockPackage)
+	for _, lockPkg := range lockFile.Packages {
+		if !lockPkg.Skipped {
+			byName[lockPkg.Name] = append(byName[lockPkg.Name], lockPkg)
+		}
+	}
+
+	type queued struct {
+		pkg   LockPackage
+		depth int
+	}
+
+	var queue []queued
+	visited := make(map[string]bool)
+	for _, key := range sortedLockKeys(lockFile) {
+		if lockPkg := lockFile.Packages[key]; lockPkg.Direct && !lockPkg.Skipped {
+			visited[key] = true
+			queue = append(queue, queued{pkg: lockPkg})
+		}
+	}
+
+	parents := make(map[string]map[string]bool)
+	found := make(map[string]LockPackage)
+
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		if maxDepth >= 0 && item.depth >= maxDepth {
+			continue
+		}
+
+		parent := fmt.Sprintf("%s@%s", item.pkg.Name, item.pkg.Version)
+		for _, depName := range sortedKeys(item.pkg.Dependencies) {
+			depRange := item.pkg.Dependencies[depName]
+
+			var children []LockPackage
+			for _, candidate := range byName[depName] {
+				if satisfiesRange(candidate.Version, depRange) {
+					children = append(children, candidate)
+				}
+			}
+			if len(children) == 0 {
+				children = byName[depName]
+			}
+
+			for _, child := range children {
+				childKey := fmt.Sprintf("%s@%s", child.Name, child.Version)
+				if !child.Direct {
+					if parents[childKey] == nil {
+						parents[childKey] = make(map[string]bool)
+					}
+					parents[childKey][fmt.Sprintf("%s (%s)", parent, depRange)] = true
+					found[childKey] = child
+				}
+				if !visited[childKey] {
+					visited[childKey] = true
+					queue = append(queue, queued{pkg: child, depth: item.depth + 1})
+				}
+			}
+		}
+	}
+
+	transitive := make([]TransitivePackage, 0, len(found))
+	for key, child := range found {
+		var parentList []string
+		for parent := range parents[key] {
+			parentList = append(parentList, parent)
+		}
+		sort.Strings(parentList)
+		transitive = append(transitive, TransitivePackage{Name: child.Name, Version: child.Version, Parents: parentList})
+	}
+
+	sort.Slice(transitive, func(i, j int) bool {
+		if transitive[i].Name != transitive[j].Name {
+			return transitive[i].Name < transitive[j].Name
+		}
+		return compareVersions(transitive[i].Version, transitive[j].Version) < 0
+	})
+	return transitive
+}
+
+func sortedLockKeys(lockFile *LockFile) []string {
+	keys := make([]string, 0, len(lockFile.Packages))
+	for key := range lockFile.Packages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func printOutdatedJSON(entries map[string]OutdatedEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal outdated report: %v", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func printOutdatedTable(entries map[string]OutdatedEntry) {
+	if len(entries) == 0 {
+		fmt.Printf(" %s All packages are up to date\n", color.GreenString("✓"))
+		return
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	nameWidth := len("Package")
+	for _, name := range names {
+		if len(name) > nameWidth {
+			nameWidth = len(name)
+		}
+	}
+
+	fmt.Printf("\n %-*s  %-12s  %-12s  %-12s\n", nameWidth, "Package", "Current", "Wanted", "Latest")
+	for _, name := range names {
+		entry := entries[name]
+
+		devTag := ""
+		if entry.Type == "devDependencies" {
+			devTag = color.HiBlackString(" (dev)")
+		} else if entry.Type == "transitive" {
+			devTag = color.HiBlackString(" (transitive)")
+		}
+		if entry.Pinned {
+			devTag += color.MagentaString(" (pinned)")
+		}
+
+		fmt.Printf(" %s  %-12s  %s  %s%s\n",
+			color.CyanString("%-*s", nameWidth, name),
+			entry.Current,
+			outdatedVersionColumn(entry.Current, entry.Wanted),
+			outdatedVersionColumn(entry.Current, entry.Latest),
+			devTag)
+		for _, parent := range entry.Parents {
+			fmt.Printf("   %s %s\n", color.HiBlackString("via"), color.HiBlackString(parent))
+		}
+	}
+	fmt.Println()
+}
+
+func outdatedVersionColumn(current, version string) string {
+	switch {
+	case version == "":
+		return color.HiBlackString("%-12s", "-")
+	case compareVersions(current, version) >= 0:
+		return fmt.Sprintf("%-12s", version)
+	case upgradeSeverity(current, version) == "major":
+		return color.RedString("%-12s", version)
+	}
+	return color.YellowString("%-12s", version)
+}
+
+func hasOutdatedAtSeverity(entries map[string]OutdatedEntry, minSeverity string) bool {
+	threshold := severityRank[minSeverity]
+	for _, entry := range entries {
+		if severityRank[entry.Severity] >= threshold {
+			return true
+		}
+	}
+	return false
+}
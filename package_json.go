@@ -1,56 +1,75 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 )
 
 type PackageJSON struct {
-	Name            string            `json:"name"`
-	Version         string            `json:"version"`
-	Description     string            `json:"description,omitempty"`
-	Main            string            `json:"main,omitempty"`
-	Scripts         map[string]string `json:"scripts,omitempty"`
-	Keywords        []string          `json:"keywords,omitempty"`
-	Author          string            `json:"author,omitempty"`
-	License         string            `json:"license,omitempty"`
-	Dependencies    map[string]string `json:"dependencies,omitempty"`
-	DevDependencies map[string]string `json:"devDependencies,omitempty"`
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Description          string            `json:"description,omitempty"`
+	Main                 string            `json:"main,omitempty"`
+	Scripts              map[string]string `json:"scripts,omitempty"`
+	Keywords             []string          `json:"keywords,omitempty"`
+	Author               string            `json:"author,omitempty"`
+	License              string            `json:"license,omitempty"`
+	Dependencies         map[string]string `json:"dependencies,omitempty"`
+	DevDependencies      map[string]string `json:"devDependencies,omitempty"`
+	OptionalDependencies map[string]string `json:"optionalDependencies,omitempty"`
+	Engines              map[string]string `json:"engines,omitempty"`
 }
 
-func updatePackageJSON(packageName, version string, isDev bool) error {
-	data, err := os.ReadFile("package.json")
-	if err != nil {
-		return fmt.Errorf("failed to read package.json: %v", err)
-	}
+var semverPattern = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-(0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(\.(0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*)?(\+[0-9a-zA-Z-]+(\.[0-9a-zA-Z-]+)*)?$`)
 
-	var pkg PackageJSON
-	if err := json.Unmarshal(data, &pkg); err != nil {
-		return fmt.Errorf("failed to parse package.json: %v", err)
+func validateVersion(version string) (string, error) {
+	normalized := strings.TrimSpace(version)
+	normalized = strings.TrimPrefix(normalized, "=")
+	normalized = strings.TrimPrefix(strings.TrimPrefix(normalized, "v"), "V")
+
+	if !semverPattern.MatchString(normalized) {
+		return "", fmt.Errorf("invalid version %q: expected semver like 1.2.3", version)
 	}
+	return normalized, nil
+}
 
-	if pkg.Dependencies == nil {
-		pkg.Dependencies = make(map[string]string)
+func savePrefix() string {
+	if config.getBool("save-exact", false) {
+		return ""
 	}
-	if pkg.DevDependencies == nil {
-		pkg.DevDependencies = make(map[string]string)
+
+	switch prefix, ok := config.values["save-prefix"]; {
+	case !ok:
+		return "^"
+	case prefix == "" || prefix == "^" || prefix == "~":
+		return prefix
 	}
+	return "^"
+}
 
+func updatePackageJSON(packageName, versionRange string, isDev bool) error {
+	section := groupDependencies
 	if isDev {
-		pkg.DevDependencies[packageName] = "^" + version
-	} else {
-		pkg.Dependencies[packageName] = "^" + version
+		section = groupDevDependencies
 	}
 
-	updatedData, err := json.MarshalIndent(pkg, "", "  ")
+	return editManifestFile("package.json", func(data []byte) ([]byte, error) {
+		return setManifestEntry(data, section, packageName, versionRange)
+	})
+}
+
+func loadPackageJSON(path string) (*PackageJSON, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to marshal package.json: %v", err)
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
 	}
 
-	if err := os.WriteFile("package.json", updatedData, 0644); err != nil {
-		return fmt.Errorf("failed to write package.json: %v", err)
+	var pkg PackageJSON
+	if err := parseManifest(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
 	}
 
-	return nil
+	return &pkg, nil
 }
@@ -2,14 +2,24 @@ package main
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/briandowns/spinner"
@@ -20,110 +30,308 @@ import (
 type PackageManager struct {
 	nodeModulesPath string
 	registryURL     string
+	mirrors         []string
+	scopeRegistries map[string]string
+	authTokens      map[string]string
 	cache           *Cache
+	frozenLock      *LockFile
+	downloadLimiter *rateLimiter
+	fetchTimeout    time.Duration
+	downloadTimeout time.Duration
+	fetchAttempts   int
+	concurrency     int
+	httpClient      *http.Client
+	metadata        *metadataCache
+	offline         bool
+	preferOffline   bool
+	offlineLock     *LockFile
+	strictRanges    bool
+	checkFiles      bool
+	transferred     transferCounter
+	replaceHost     string
+	extractWorkers  int
+	engineStrict    bool
+	ignoreEngines   bool
+	force           bool
+	onlyMissing     bool
+	installPeers    bool
+	before          time.Time
+	timings         int
+	auditRegistry   string
+	noVerify        bool
+	resumed         map[string]string
+	directPackages  map[string]bool
+
+	dependencyFailures dependencyFailureLog
 }
 
 type PackageInfo struct {
-	Name    string   `json:"name"`
-	Version string   `json:"version"`
-	Dist    DistInfo `json:"dist"`
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Dependencies map[string]string `json:"dependencies"`
+	OS           []string          `json:"os"`
+	CPU          []string          `json:"cpu"`
+	Engines      map[string]string `json:"engines"`
+	Dist         DistInfo          `json:"dist"`
 }
 
 type DistInfo struct {
-	Tarball string `json:"tarball"`
-	Shasum  string `json:"shasum"`
+	Tarball   string `json:"tarball"`
+	Shasum    string `json:"shasum"`
+	Integrity string `json:"integrity"`
 }
 
+type httpStatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("failed to download package: status %d", e.StatusCode)
+}
+
+type frozenIntegrityError struct {
+	err error
+}
+
+func (e *frozenIntegrityError) Error() string {
+	return e.err.Error()
+}
+
+type truncatedDownloadError struct {
+	err error
+}
+
+func (e *truncatedDownloadError) Error() string {
+	return fmt.Sprintf("download truncated: %v", e.err)
+}
+
+type extractError struct {
+	err error
+}
+
+func (e *extractError) Error() string {
+	return fmt.Sprintf("failed to extract package: %v", e.err)
+}
+
+const (
+	defaultFetchTimeout    = 10 * time.Second
+	defaultDownloadTimeout = 60 * time.Second
+)
+
 type RegistryResponse struct {
 	Versions map[string]PackageInfo `json:"versions"`
 	DistTags map[string]string      `json:"dist-tags"`
 }
 
 func NewPackageManager() *PackageManager {
-	return &PackageManager{
-		nodeModulesPath: "./node_modules",
+	pm := &PackageManager{
+		nodeModulesPath: nodeModulesDir(),
 		registryURL:     "https://registry.npmjs.org",
 		cache:           NewCache(),
+		fetchTimeout:    config.getDuration("fetch-timeout", defaultFetchTimeout),
+		downloadTimeout: config.getDuration("download-timeout", defaultDownloadTimeout),
+		fetchAttempts:   config.getInt("fetch-attempts", defaultFetchAttempts),
+		concurrency:     min(config.getInt("concurrency", defaultConcurrency()), maxConcurrency),
+		httpClient:      sharedHTTPClient,
+		strictRanges:    config.getBool("strict-ranges", false),
+		replaceHost:     config.getDefault("replace-registry-host", "never"),
+		extractWorkers:  config.getInt("extract-concurrency", defaultExtractWorkers()),
+		engineStrict:    config.getBool("engine-strict", false),
+		ignoreEngines:   config.getBool("ignore-engines", false),
+		installPeers:    config.getBool("install-peers", false),
+		auditRegistry:   config.get("audit-registry"),
+		scopeRegistries: config.scopeRegistries(),
+		authTokens:      config.authTokens(),
+		offline:         config.getBool("offline", false),
+		preferOffline:   config.getBool("prefer-offline", false),
+	}
+
+	pm.metadata = newMetadataCache("")
+	if config.getBool("metadata-cache", true) {
+		pm.metadata = newMetadataCache(filepath.Join(pm.cache.cacheDir, metadataCacheDirName))
+	}
+
+	var registries []string
+	for _, registry := range strings.Split(config.get("registries"), ",") {
+		registry = strings.TrimSuffix(strings.TrimSpace(registry), "/")
+		if registry != "" {
+			registries = append(registries, registry)
+		}
+	}
+	if len(registries) > 0 {
+		pm.registryURL = registries[0]
+		pm.mirrors = registries[1:]
+	} else if registry := strings.TrimSuffix(config.get("registry"), "/"); registry != "" {
+		pm.registryURL = registry
+	}
+
+	if value := os.Getenv("GPM_CONCURRENCY"); value != "" {
+		if concurrency, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && concurrency >= 1 {
+			pm.concurrency = min(concurrency, maxConcurrency)
+		} else {
+			color.Yellow("Ignoring GPM_CONCURRENCY=%s (expected a positive number)", value)
+		}
 	}
+
+	return pm
+}
+
+func (pm *PackageManager) registries() []string {
+	return append([]string{pm.registryURL}, pm.mirrors...)
 }
 
 func (pm *PackageManager) Install(packageName, version string) (string, bool, error) {
+	pkgInfo, err := pm.Resolve(packageName, version)
+	if err != nil {
+		return "", false, err
+	}
 
-	if err := pm.ensureNodeModulesDir(); err != nil {
-		return "", false, fmt.Errorf("failed to create node_modules directory: %v", err)
+	wasCached, err := pm.Fetch(packageName, pkgInfo)
+	if err != nil {
+		return "", false, err
 	}
 
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = fmt.Sprintf(" %s Resolving %s@%s", color.CyanString("→"), color.CyanString(packageName), color.HiBlackString(version))
-	s.Color("cyan")
-	s.Start()
+	return pkgInfo.Version, wasCached, nil
+}
+
+func (pm *PackageManager) Resolve(packageName, version string) (*PackageInfo, error) {
+	var s *spinner.Spinner
+	if animateOutput() {
+		s = spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithWriter(output))
+		s.Suffix = fmt.Sprintf(" %s Resolving %s@%s", color.CyanString("→"), color.CyanString(packageName), color.HiBlackString(version))
+		s.Color("cyan")
+		s.Start()
+	}
 
 	pkgInfo, err := pm.getPackageInfo(packageName, version)
-	s.Stop()
-	fmt.Print("\r                                                                \r")
+	if s != nil {
+		s.Stop()
+	}
+	clearLine()
 
 	if err != nil {
-		return "", false, fmt.Errorf("failed to get package info: %v", err)
+		return nil, fmt.Errorf("failed to get package info: %v", err)
+	}
+	reporter.Report(InstallEvent{Type: "resolved", Package: packageName, Version: pkgInfo.Version})
+
+	return pkgInfo, nil
+}
+
+func (pm *PackageManager) Fetch(packageName string, pkgInfo *PackageInfo) (bool, error) {
+	if err := pm.ensureNodeModulesDir(); err != nil {
+		return false, fmt.Errorf("failed to create node_modules directory: %v", err)
 	}
 
 	packagePath := filepath.Join(pm.nodeModulesPath, packageName)
-	if pm.isPackageInstalled(packagePath, pkgInfo.Version) {
-		fmt.Printf(" %s %s@%s %s\n", color.HiGreenString("✓"), color.CyanString(packageName), color.HiBlackString(pkgInfo.Version), color.HiBlackString("(cached)"))
-		return pkgInfo.Version, true, nil
+	if pm.isInstalled(packagePath, pkgInfo.Name, pkgInfo.Version) {
+		if reporter.Human() {
+			output.Printf(" %s %s@%s %s\n", color.HiGreenString("✓"), color.CyanString(packageName), color.HiBlackString(pkgInfo.Version), color.HiBlackString("(cached)"))
+		}
+		return true, nil
+	}
+	if pm.checkFiles && pm.isPackageInstalled(packagePath, pkgInfo.Version) {
+		missing := pm.missingPackageFile(packagePath, pkgInfo.Name, pkgInfo.Version)
+		reportWarning("%s@%s is incomplete (%s is missing or damaged), reinstalling", packageName, pkgInfo.Version, missing)
 	}
 
-	if pm.cache.hasPackage(packageName, pkgInfo.Version) {
-		if err := pm.installFromCache(packageName, pkgInfo.Version, packagePath); err == nil {
-			return pkgInfo.Version, true, nil
+	expectedIntegrity, err := pm.lockedIntegrity(packageName, pkgInfo.Version)
+	if err != nil {
+		return false, err
+	}
+
+	if pm.cache.hasPackage(pkgInfo.Name, pkgInfo.Version) && pm.cachedIntegrityComparable(pkgInfo.Name, pkgInfo.Version, expectedIntegrity) {
+		if err := pm.verifyCachedIntegrity(pkgInfo.Name, pkgInfo.Version, expectedIntegrity); err != nil {
+			return false, err
+		}
+		if err := pm.installFromCache(pkgInfo.Name, pkgInfo.Version, packagePath); err == nil {
+			return true, nil
 		}
 	}
 
-	if err := pm.downloadAndExtract(pkgInfo, packagePath); err != nil {
-		return "", false, fmt.Errorf("failed to download and extract package: %v", err)
+	if err := pm.downloadAndExtract(pkgInfo, packagePath, expectedIntegrity); err != nil {
+		return false, fmt.Errorf("failed to download and extract package: %v", err)
 	}
 
-	return pkgInfo.Version, false, nil
+	return false, nil
 }
 
-func (pm *PackageManager) ensureNodeModulesDir() error {
-	return os.MkdirAll(pm.nodeModulesPath, 0755)
-}
+func (pm *PackageManager) lockedIntegrity(name, version string) (string, error) {
+	if pm.frozenLock == nil {
+		return "", nil
+	}
 
-func (pm *PackageManager) getPackageInfo(packageName, version string) (*PackageInfo, error) {
-	url := fmt.Sprintf("%s/%s", pm.registryURL, packageName)
+	integrity := pm.frozenLock.getIntegrity(name, version)
+	if integrity == "" {
+		return "", fmt.Errorf("no integrity recorded in %s for %s@%s", lockFileName(), name, version)
+	}
+	return integrity, nil
+}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+func (pm *PackageManager) verifyCachedIntegrity(name, version, expected string) error {
+	if expected == "" {
+		return nil
 	}
 
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch package info: %v", err)
+	if actual := pm.cache.getIntegrity(name, version); !integrityMatches(expected, actual) {
+		return fmt.Errorf("cache entry for %s@%s does not match %s: expected %s, got %s", name, version, lockFileName(), expected, matchingDigest(expected, actual))
 	}
-	defer resp.Body.Close()
+	return nil
+}
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("package '%s' not found in npm registry", packageName)
+func (pm *PackageManager) cachedIntegrityComparable(name, version, expected string) bool {
+	return expected == "" || sharesIntegrityAlgorithm(expected, pm.cache.getIntegrity(name, version))
+}
+
+func shasumToIntegrity(shasum string) string {
+	digest, err := hex.DecodeString(shasum)
+	if err != nil || len(digest) == 0 {
+		return ""
 	}
+	return "sha1-" + base64.StdEncoding.EncodeToString(digest)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("npm registry error: status %d", resp.StatusCode)
+func (pm *PackageManager) ensureNodeModulesDir() error {
+	return os.MkdirAll(pm.nodeModulesPath, 0755)
+}
+
+func (pm *PackageManager) getPackageInfo(packageName, version string) (*PackageInfo, error) {
+	if pkgInfo, ok := pm.lockedPackageInfo(packageName, version); ok {
+		return pkgInfo, nil
 	}
 
-	var registryResp RegistryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&registryResp); err != nil {
-		return nil, fmt.Errorf("failed to parse registry response: %v", err)
+	isRange := isVersionRange(version)
+
+	index, err := pm.fetchPackumentIndex(packageName, func(v string, distTags map[string]string) bool {
+		switch {
+		case version == "latest":
+			return v == distTags["latest"]
+		case isRange:
+			return v == distTags["latest"] || satisfiesRange(v, version)
+		}
+		return v == version
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !pm.before.IsZero() {
+		if err := index.publishedBefore(packageName, pm.before); err != nil {
+			return nil, err
+		}
 	}
 
+	registryResp := &RegistryResponse{DistTags: index.DistTags, Versions: index.versionStubs()}
 
 	if version == "latest" {
 		if latestVersion, ok := registryResp.DistTags["latest"]; ok {
 			version = latestVersion
+		} else if !pm.before.IsZero() {
+			return nil, fmt.Errorf("no version of %s was published before %s", packageName, pm.before.Format(time.RFC3339))
 		} else {
 			return nil, fmt.Errorf("no latest version found for %s", packageName)
 		}
-	} else if strings.Contains(version, "x") || strings.Contains(version, "||") || strings.Contains(version, "^") || strings.Contains(version, "~") {
-		resolvedVersion := pm.resolveVersionRange(version, registryResp.Versions)
+	} else if isRange {
+		resolvedVersion := resolveVersionRange(version, registryResp.Versions)
 		if resolvedVersion == "" {
 			if latestVersion, ok := registryResp.DistTags["latest"]; ok {
 				version = latestVersion
@@ -135,14 +343,201 @@ func (pm *PackageManager) getPackageInfo(packageName, version string) (*PackageI
 		}
 	}
 
-	pkgInfo, ok := registryResp.Versions[version]
-	if !ok {
+	if _, ok := registryResp.Versions[version]; !ok {
+		if !pm.before.IsZero() {
+			return nil, fmt.Errorf("version %s of %s was not published before %s", version, packageName, pm.before.Format(time.RFC3339))
+		}
 		return nil, fmt.Errorf("version %s not found for package %s", version, packageName)
 	}
 
+	pkgInfo, ok := index.Kept[version]
+	if !ok {
+		index, err = pm.fetchPackumentIndex(packageName, func(v string, distTags map[string]string) bool {
+			return v == version
+		})
+		if err != nil {
+			return nil, err
+		}
+		if pkgInfo, ok = index.Kept[version]; !ok {
+			return nil, fmt.Errorf("version %s not found for package %s", version, packageName)
+		}
+	}
+
 	return &pkgInfo, nil
 }
 
+func (pm *PackageManager) fetchRegistryResponse(packageName string) (*RegistryResponse, error) {
+	var lastErr error
+
+	for _, registry := range pm.registriesFor(packageName) {
+		registryResp, err := pm.fetchRegistryResponseFrom(registry, packageName)
+		if err == nil {
+			return registryResp, nil
+		}
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+func (pm *PackageManager) fetchRegistryResponseFrom(registry, packageName string) (*RegistryResponse, error) {
+	body, err := pm.openRegistryDocument(registry, packageName)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var registryResp RegistryResponse
+	if err := json.NewDecoder(body).Decode(&registryResp); err != nil {
+		return nil, fmt.Errorf("failed to parse registry response: %v", err)
+	}
+
+	return &registryResp, nil
+}
+
+func (pm *PackageManager) fetchPackumentIndex(packageName string, keep func(version string, distTags map[string]string) bool) (*packumentIndex, error) {
+	var lastErr error
+
+	for _, registry := range pm.registriesFor(packageName) {
+		body, err := pm.openRegistryDocument(registry, packageName)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		index, err := decodePackumentIndex(body, keep)
+		body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to parse registry response: %v", err)
+			continue
+		}
+		return index, nil
+	}
+
+	return nil, lastErr
+}
+
+func (pm *PackageManager) openRegistryDocument(registry, packageName string) (io.ReadCloser, error) {
+	entry, err := pm.metadata.get(registry+"/"+packageName, func(entry *metadataEntry) error {
+		return pm.retry(packageName, func() error {
+			return pm.fetchRegistryDocument(entry, registry, packageName)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry.open()
+}
+
+func (pm *PackageManager) fetchRegistryDocument(entry *metadataEntry, registry, packageName string) error {
+	cacheKey := registry + "/" + packageName
+	etag, cachedPath := pm.metadata.load(cacheKey)
+	if pm.offline || (pm.preferOffline && cachedPath != "") {
+		path, err := pm.offlineMetadata(packageName, cachedPath)
+		entry.path = path
+		return err
+	}
+
+	resp, cancel, err := pm.requestRegistryDocument(registry, packageName, etag)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		entry.path = cachedPath
+		return nil
+	}
+
+	if err := pm.metadata.spool(entry, cacheKey, resp.Header.Get("ETag"), resp.Body); err != nil {
+		return classifyRequestError("failed to fetch package info", resp.Request.URL.String(), err)
+	}
+	return nil
+}
+
+func (pm *PackageManager) requestRegistryDocument(registry, packageName, etag string) (*http.Response, context.CancelFunc, error) {
+	url := fmt.Sprintf("%s/%s", registry, packageName)
+
+	ctx, cancel := requestContext(pm.fetchTimeout)
+	req, err := pm.newRegistryRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("failed to fetch package info: %v", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := pm.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, nil, classifyRequestError("failed to fetch package info", url, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK, resp.StatusCode == http.StatusNotModified && etag != "":
+		return resp, cancel, nil
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		cancel()
+		return nil, nil, fmt.Errorf("package '%s' not found in npm registry", packageName)
+	}
+
+	resp.Body.Close()
+	cancel()
+	return nil, nil, registryStatusError(url, resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After")))
+}
+
+func (pm *PackageManager) rewriteTarballHost(tarball string) string {
+	if pm.replaceHost == "never" {
+		return tarball
+	}
+
+	tarballURL, err := url.Parse(tarball)
+	if err != nil {
+		return tarball
+	}
+	registryURL, err := url.Parse(pm.registryURL)
+	if err != nil || tarballURL.Host == registryURL.Host {
+		return tarball
+	}
+
+	switch pm.replaceHost {
+	case "always":
+	case "npmjs":
+		if tarballURL.Host != "registry.npmjs.org" {
+			return tarball
+		}
+	default:
+		if tarballURL.Hostname() != pm.replaceHost && tarballURL.Host != pm.replaceHost {
+			return tarball
+		}
+	}
+
+	tarballURL.Scheme = registryURL.Scheme
+	tarballURL.Host = registryURL.Host
+	tarballURL.Path = strings.TrimSuffix(registryURL.Path, "/") + tarballURL.Path
+	if tarballURL.RawPath != "" {
+		tarballURL.RawPath = strings.TrimSuffix(registryURL.EscapedPath(), "/") + tarballURL.RawPath
+	}
+	return tarballURL.String()
+}
+
+func (pm *PackageManager) tarballURLs(tarball string) []string {
+	tarball = pm.rewriteTarballHost(tarball)
+	urls := []string{tarball}
+	if !strings.HasPrefix(tarball, pm.registryURL+"/") {
+		return urls
+	}
+
+	path := strings.TrimPrefix(tarball, pm.registryURL)
+	for _, mirror := range pm.mirrors {
+		urls = append(urls, mirror+path)
+	}
+	return urls
+}
+
 func (pm *PackageManager) isPackageInstalled(packagePath, version string) bool {
 	packageJSONPath := filepath.Join(packagePath, "package.json")
 
@@ -162,66 +557,183 @@ func (pm *PackageManager) isPackageInstalled(packagePath, version string) bool {
 	return pkg.Version == version
 }
 
-func (pm *PackageManager) downloadAndExtract(pkgInfo *PackageInfo, destPath string) error {
-	client := &http.Client{
-		Timeout: 60 * time.Second,
+func (pm *PackageManager) downloadAndExtract(pkgInfo *PackageInfo, destPath, expectedIntegrity string) error {
+	if pm.offline {
+		return fmt.Errorf("%s@%s is not in the cache; cannot download it with --offline", pkgInfo.Name, pkgInfo.Version)
 	}
 
-	resp, err := client.Get(pkgInfo.Dist.Tarball)
+	var lastErr error
+
+	for _, url := range pm.tarballURLs(pkgInfo.Dist.Tarball) {
+		err := pm.retry(pkgInfo.Name, func() error {
+			return pm.downloadAndExtractOnce(url, pkgInfo, destPath, expectedIntegrity)
+		})
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		var frozenErr *frozenIntegrityError
+		var extractErr *extractError
+		if errors.As(err, &frozenErr) || errors.As(err, &extractErr) {
+			return err
+		}
+	}
+
+	return lastErr
+}
+
+func (pm *PackageManager) downloadAndExtractOnce(url string, pkgInfo *PackageInfo, destPath, expectedIntegrity string) error {
+	ctx, cancel := requestContext(pm.downloadTimeout)
+	defer cancel()
+
+	req, err := pm.newRegistryRequest(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to download package: %v", err)
 	}
+
+	resp, err := pm.httpClient.Do(req)
+	if err != nil {
+		return classifyRequestError("failed to download package", url, err)
+	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download package: status %d", resp.StatusCode)
-	}
-
-	bar := progressbar.NewOptions64(
-		resp.ContentLength,
-		progressbar.OptionSetDescription(fmt.Sprintf(" %s %s", color.CyanString("↓"), pkgInfo.Name)),
-		progressbar.OptionSetWidth(20),
-		progressbar.OptionShowBytes(true),
-		progressbar.OptionClearOnFinish(),
-		progressbar.OptionSetRenderBlankState(false),
-		progressbar.OptionThrottle(50*time.Millisecond),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "█",
-			SaucerHead:    "█",
-			SaucerPadding: "░",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}),
-	)
-
-	reader := progressbar.NewReader(resp.Body, bar)
-
-	gzipReader, err := gzip.NewReader(&reader)
+		return &httpStatusError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	var body io.Reader = resp.Body
+	if pm.downloadLimiter != nil {
+		body = &rateLimitedReader{reader: body, limiter: pm.downloadLimiter}
+	}
+	if animateOutput() {
+		bar := progressbar.NewOptions64(
+			resp.ContentLength,
+			progressbar.OptionSetWriter(output),
+			progressbar.OptionSetDescription(fmt.Sprintf(" %s %s", color.CyanString("↓"), pkgInfo.Name)),
+			progressbar.OptionSetWidth(20),
+			progressbar.OptionShowBytes(true),
+			progressbar.OptionShowIts(),
+			progressbar.OptionSetPredictTime(true),
+			progressbar.OptionClearOnFinish(),
+			progressbar.OptionSetRenderBlankState(false),
+			progressbar.OptionThrottle(50*time.Millisecond),
+			progressbar.OptionSetTheme(progressbar.Theme{
+				Saucer:        "█",
+				SaucerHead:    "█",
+				SaucerPadding: "░",
+				BarStart:      "[",
+				BarEnd:        "]",
+			}),
+		)
+
+		reader := progressbar.NewReader(body, bar)
+		body = &reader
+	}
+
+	tarball, err := pm.cache.createTarball(pkgInfo.Name, pkgInfo.Version)
+	if err != nil {
+		return fmt.Errorf("failed to cache package: %v", err)
+	}
+	defer os.Remove(tarball.Name())
+	defer tarball.Close()
+
+	hasher := newIntegrityHasher()
+	var received byteCounter
+	stream := io.TeeReader(body, io.MultiWriter(hasher, &received, &pm.transferred, tarball))
+
+	tmpDest, err := makeStagingDir(destPath)
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDest)
+
+	shortBody := func() bool {
+		io.Copy(io.Discard, stream)
+		return resp.ContentLength >= 0 && int64(received) < resp.ContentLength
+	}
+
+	gzipReader, err := gzip.NewReader(stream)
 	if err != nil {
+		if isTruncatedStream(err) || shortBody() {
+			return &truncatedDownloadError{err: err}
+		}
 		return fmt.Errorf("failed to create gzip reader: %v", err)
 	}
 	defer gzipReader.Close()
 
 	tarReader := tar.NewReader(gzipReader)
 
-	if err := pm.extractAndCache(tarReader, destPath, pkgInfo.Name, pkgInfo.Version); err != nil {
-		return fmt.Errorf("failed to extract package: %v", err)
+	if err := extractPackage(tarReader, tmpDest, pm.extractWorkers); err != nil {
+		if isTruncatedStream(err) || shortBody() {
+			return &truncatedDownloadError{err: err}
+		}
+		return &extractError{err: err}
 	}
 
-	return nil
-}
+	_, err = io.Copy(io.Discard, stream)
+	if resp.ContentLength >= 0 && int64(received) != resp.ContentLength {
+		return &truncatedDownloadError{err: fmt.Errorf("received %d of %d bytes", received, resp.ContentLength)}
+	}
+	if err != nil {
+		return &truncatedDownloadError{err: err}
+	}
 
-func (pm *PackageManager) extractAndCache(tarReader *tar.Reader, destPath, packageName, version string) error {
-	cachePath := pm.cache.getPackagePath(packageName, version)
+	integrity := hasher.Integrity()
+	if !pm.noVerify {
+		if err := verifyDistIntegrity(pkgInfo, integrity); err != nil {
+			return err
+		}
+	}
 
-	if err := os.RemoveAll(destPath); err != nil {
-		return err
+	if expectedIntegrity != "" && !integrityMatches(expectedIntegrity, integrity) {
+		return &frozenIntegrityError{err: fmt.Errorf("downloaded %s@%s does not match %s: expected %s, got %s", pkgInfo.Name, pkgInfo.Version, lockFileName(), expectedIntegrity, matchingDigest(expectedIntegrity, integrity))}
 	}
-	if err := os.RemoveAll(cachePath); err != nil {
-		return err
+
+	if err := replaceDirectory(tmpDest, destPath); err != nil {
+		return fmt.Errorf("failed to install package: %v", err)
 	}
+	if err := tarball.Close(); err != nil {
+		return fmt.Errorf("failed to cache package: %v", err)
+	}
+	if err := pm.cache.storePackage(pkgInfo.Name, pkgInfo.Version, tarball.Name()); err != nil {
+		return fmt.Errorf("failed to cache package: %v", err)
+	}
+	if err := pm.cache.storeIntegrity(pkgInfo.Name, pkgInfo.Version, integrity); err != nil {
+		return fmt.Errorf("failed to cache package: %v", err)
+	}
+
+	return nil
+}
+
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+func isTruncatedStream(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, gzip.ErrChecksum) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.As(err, &netErr)
+}
+
+func extractPackage(tarReader *tar.Reader, destPath string, workers int) error {
+	caseInsensitive := isCaseInsensitiveDir(destPath)
+	seenPaths := make(map[string]string)
+	written := make(map[string]bool)
+
+	pool := newExtractPool(workers)
+	defer pool.wait()
 
 	for {
+		if err := pool.failed(); err != nil {
+			return err
+		}
+
 		header, err := tarReader.Next()
 		if err == io.EOF {
 			break
@@ -236,7 +748,6 @@ func (pm *PackageManager) extractAndCache(tarReader *tar.Reader, destPath, packa
 		}
 
 		target := filepath.Join(destPath, path)
-		cacheTarget := filepath.Join(cachePath, path)
 
 		cleanDest := filepath.Clean(destPath)
 		cleanTarget := filepath.Clean(target)
@@ -244,12 +755,18 @@ func (pm *PackageManager) extractAndCache(tarReader *tar.Reader, destPath, packa
 			continue
 		}
 
+		foldedPath := strings.ToLower(filepath.Clean(path))
+		if existing, ok := seenPaths[foldedPath]; ok && existing != filepath.Clean(path) {
+			if caseInsensitive {
+				return fmt.Errorf("paths %s and %s differ only in case and would overwrite each other on this filesystem", existing, path)
+			}
+			reportWarning("%s and %s differ only in case and will collide on case-insensitive filesystems", existing, path)
+		}
+		seenPaths[foldedPath] = filepath.Clean(path)
+
 		switch header.Typeflag {
 		case tar.TypeDir:
-			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
-				return err
-			}
-			if err := os.MkdirAll(cacheTarget, os.FileMode(header.Mode)); err != nil {
+			if err := os.MkdirAll(target, 0755); err != nil {
 				return err
 			}
 
@@ -257,36 +774,121 @@ func (pm *PackageManager) extractAndCache(tarReader *tar.Reader, destPath, packa
 			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
 				return err
 			}
-			if err := os.MkdirAll(filepath.Dir(cacheTarget), 0755); err != nil {
-				return err
+
+			if written[cleanTarget] {
+				if err := pool.wait(); err != nil {
+					return err
+				}
 			}
+			written[cleanTarget] = true
 
-			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
-			if err != nil {
-				return err
+			mode := normalizedFileMode(os.FileMode(header.Mode))
+
+			if pool.workers <= 1 || header.Size > maxBufferedExtractSize {
+				if err := writeExtractedFile(target, mode, tarReader); err != nil {
+					return err
+				}
+				continue
 			}
 
-			cacheFile, err := os.OpenFile(cacheTarget, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+			data, err := io.ReadAll(tarReader)
 			if err != nil {
-				file.Close()
 				return err
 			}
+			pool.submit(func() error {
+				return writeExtractedFile(target, mode, bytes.NewReader(data))
+			})
+		}
+	}
 
-			writer := io.MultiWriter(file, cacheFile)
-			if _, err := io.Copy(writer, tarReader); err != nil {
-				file.Close()
-				cacheFile.Close()
-				return err
-			}
-			file.Close()
-			cacheFile.Close()
+	if err := pool.wait(); err != nil {
+		return err
+	}
+
+	return normalizeTimes(destPath)
+}
+
+func writeExtractedFile(target string, mode os.FileMode, r io.Reader) error {
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(target, mode)
+}
+
+var normalizedModTime = time.Date(1985, time.October, 26, 8, 15, 0, 0, time.UTC)
+
+func normalizedFileMode(mode os.FileMode) os.FileMode {
+	if mode&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+func normalizeTimes(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
 		}
+		return os.Chtimes(path, normalizedModTime, normalizedModTime)
+	})
+}
+
+func isCaseInsensitiveDir(dir string) bool {
+	probe, err := os.CreateTemp(dir, ".gpm-case-probe-")
+	if err != nil {
+		return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
 	}
+	probePath := probe.Name()
+	probe.Close()
+	defer os.Remove(probePath)
 
-	return nil
+	upper := filepath.Join(filepath.Dir(probePath), strings.ToUpper(filepath.Base(probePath)))
+	_, err = os.Stat(upper)
+	return err == nil
+}
+
+func makeStagingDir(finalPath string) (string, error) {
+	parent := filepath.Dir(finalPath)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(parent, "."+filepath.Base(finalPath)+"-*")
+	if err != nil {
+		return "", err
+	}
+	if err := os.Chmod(dir, 0755); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+func replaceDirectory(src, dst string) error {
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
 }
 
 func (pm *PackageManager) InstallDependencies(packageName string, lockFile *LockFile) error {
+	pm.installDependencyTree(packageName, lockFile, make(map[string]bool))
+	return nil
+}
+
+func (pm *PackageManager) installDependencyTree(packageName string, lockFile *LockFile, visited map[string]bool) []string {
+	if visited[packageName] {
+		return nil
+	}
+	visited[packageName] = true
+
 	packagePath := filepath.Join(pm.nodeModulesPath, packageName)
 	packageJSONPath := filepath.Join(packagePath, "package.json")
 
@@ -296,160 +898,207 @@ func (pm *PackageManager) InstallDependencies(packageName string, lockFile *Lock
 	}
 
 	var pkg struct {
-		Dependencies map[string]string `json:"dependencies"`
+		Dependencies         map[string]string `json:"dependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
 	}
 	if err := json.Unmarshal(data, &pkg); err != nil {
 		return nil
 	}
 
-	for depName := range pkg.Dependencies {
-		depPath := filepath.Join(pm.nodeModulesPath, depName)
-		if _, err := os.Stat(depPath); err == nil {
+	var installed []string
+
+	for _, depName := range sortedKeys(pkg.Dependencies) {
+		if pm.directPackages[depName] {
 			continue
 		}
+		depRange := pkg.Dependencies[depName]
+		if pm.hasInstalledDependency(depName) {
+			pm.checkInstalledRange(packageName, depName, depRange)
+		} else {
+			version := registryVersion(depRange)
+			if locked := lockFile.getPackageVersion(depName); pm.onlyMissing && locked != "" && satisfiesRange(locked, depRange) {
+				version = locked
+			}
+			if pm.frozenLock != nil {
+				version = pm.frozenLock.getPackageVersion(depName)
+				if version == "" {
+					pm.recordDependencyFailure(packageName, depName, pkg.Dependencies[depName], fmt.Errorf("not in %s", lockFileName()))
+					continue
+				}
+			}
 
-		installedVersion, err := pm.installSimple(depName, "latest")
-		if err != nil {
-			continue
+			pkgInfo, err := pm.installSimple(depName, version, false)
+			if err != nil {
+				pm.recordDependencyFailure(packageName, depName, version, err)
+				continue
+			}
+
+			if err := lockFile.addPackage(depName, pkgInfo.Version, depName, false); err != nil {
+				pm.recordDependencyFailure(packageName, depName, pkgInfo.Version, err)
+				continue
+			}
+			lockFile.setResolution(depName, pkgInfo.Version, pkgInfo.Dist.Tarball, distIntegrity(pkgInfo.Dist))
+			installed = append(installed, fmt.Sprintf("%s@%s", depName, pkgInfo.Version))
 		}
 
-		if err := lockFile.addPackage(depName, installedVersion, depName, false); err != nil {
+		installed = append(installed, pm.installDependencyTree(depName, lockFile, visited)...)
+	}
+
+	for _, depName := range sortedKeys(pkg.OptionalDependencies) {
+		if pm.directPackages[depName] {
 			continue
 		}
+		depRange := pkg.OptionalDependencies[depName]
+		if pm.hasInstalledDependency(depName) {
+			pm.checkInstalledRange(packageName, depName, depRange)
+		} else {
+			version := registryVersion(depRange)
+			if pm.frozenLock != nil {
+				version = pm.frozenLock.getPackageVersion(depName)
+				if version == "" {
+					continue
+				}
+			}
+
+			pkgInfo, err := pm.installSimple(depName, version, true)
+			if err != nil {
+				if pkgInfo != nil {
+					version = pkgInfo.Version
+				}
+				reportWarning("Skipped optional dependency %s of %s: %v", depName, packageName, err)
+				lockFile.addSkippedOptional(depName, version, false)
+				continue
+			}
+
+			if err := lockFile.addPackage(depName, pkgInfo.Version, depName, false); err != nil {
+				pm.recordDependencyFailure(packageName, depName, pkgInfo.Version, err)
+				continue
+			}
+			lockFile.setResolution(depName, pkgInfo.Version, pkgInfo.Dist.Tarball, distIntegrity(pkgInfo.Dist))
+			lockFile.markOptional(depName, pkgInfo.Version)
+			installed = append(installed, fmt.Sprintf("%s@%s", depName, pkgInfo.Version))
+		}
+
+		installed = append(installed, pm.installDependencyTree(depName, lockFile, visited)...)
 	}
 
-	return nil
+	return installed
 }
 
-func (pm *PackageManager) installSimple(packageName, version string) (string, error) {
+func registryVersion(depRange string) string {
+	depRange = strings.TrimSpace(depRange)
+	if depRange == "" || depRange == "*" || strings.Contains(depRange, ":") || strings.Contains(depRange, "/") {
+		return "latest"
+	}
+	if _, err := parseSemver(depRange); err == nil {
+		return strings.TrimPrefix(strings.TrimPrefix(depRange, "="), "v")
+	}
+	if isVersionRange(depRange) {
+		return depRange
+	}
+	return "latest"
+}
+
+func (pm *PackageManager) checkInstalledRange(parent, depName, depRange string) {
+	manifest, err := readInstalledManifest(filepath.Join(pm.nodeModulesPath, depName))
+	if err != nil || manifest.Version == "" || satisfiesRange(manifest.Version, depRange) {
+		return
+	}
+	reportWarning("%s requires %s@%s, but %s is installed", parent, depName, depRange, manifest.Version)
+}
+
+func (pm *PackageManager) installSimple(packageName, version string, optional bool) (*PackageInfo, error) {
 	pkgInfo, err := pm.getPackageInfo(packageName, version)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if optional {
+		if err := checkPlatform(pkgInfo); err != nil {
+			return pkgInfo, err
+		}
+	}
+
+	if err := pm.checkEngines(pkgInfo); err != nil {
+		return pkgInfo, err
 	}
 
 	packagePath := filepath.Join(pm.nodeModulesPath, packageName)
-	if pm.isPackageInstalled(packagePath, pkgInfo.Version) {
-		return pkgInfo.Version, nil
+	if pm.isInstalled(packagePath, pkgInfo.Name, pkgInfo.Version) {
+		return pkgInfo, nil
 	}
 
-	if pm.cache.hasPackage(packageName, pkgInfo.Version) {
+	expectedIntegrity, err := pm.lockedIntegrity(packageName, pkgInfo.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	if pm.cache.hasPackage(packageName, pkgInfo.Version) && pm.cachedIntegrityComparable(packageName, pkgInfo.Version, expectedIntegrity) {
+		if err := pm.verifyCachedIntegrity(packageName, pkgInfo.Version, expectedIntegrity); err != nil {
+			return nil, err
+		}
 		if err := pm.installFromCache(packageName, pkgInfo.Version, packagePath); err == nil {
-			return pkgInfo.Version, nil
+			return pkgInfo, nil
 		}
 	}
 
-	if err := pm.downloadAndExtract(pkgInfo, packagePath); err != nil {
-		return "", err
+	if err := pm.downloadAndExtract(pkgInfo, packagePath, expectedIntegrity); err != nil {
+		return pkgInfo, err
 	}
 
-	return pkgInfo.Version, nil
+	return pkgInfo, nil
 }
 
 func (pm *PackageManager) installFromCache(packageName, version, destPath string) error {
-	cachePath := pm.cache.getPackagePath(packageName, version)
-	return copyDirectory(cachePath, destPath)
-}
-
-func (pm *PackageManager) resolveVersionRange(versionRange string, availableVersions map[string]PackageInfo) string {
-	if strings.Contains(versionRange, "||") {
-		parts := strings.Split(versionRange, "||")
-		for _, part := range parts {
-			part = strings.TrimSpace(part)
-			resolved := pm.resolveSingleVersion(part, availableVersions)
-			if resolved != "" {
-				return resolved
-			}
-		}
-	} else {
-		return pm.resolveSingleVersion(versionRange, availableVersions)
+	tmpDest, err := makeStagingDir(destPath)
+	if err != nil {
+		return err
 	}
-	return ""
-}
-
-func (pm *PackageManager) resolveSingleVersion(version string, availableVersions map[string]PackageInfo) string {
-	version = strings.TrimSpace(version)
+	defer os.RemoveAll(tmpDest)
 
-	if strings.Contains(version, "x") {
-		pattern := strings.ReplaceAll(version, "x", "")
-		pattern = strings.TrimSuffix(pattern, ".")
+	if err := pm.cache.copyToNodeModules(packageName, version, tmpDest, pm.extractWorkers); err != nil {
+		return err
+	}
+	return replaceDirectory(tmpDest, destPath)
+}
 
-		var bestVersion string
-		for v := range availableVersions {
-			if strings.HasPrefix(v, pattern) {
-				if bestVersion == "" || pm.compareVersions(v, bestVersion) > 0 {
-					bestVersion = v
-				}
-			}
-		}
-		return bestVersion
-	}
-
-	if strings.HasPrefix(version, "^") {
-		baseVersion := strings.TrimPrefix(version, "^")
-		parts := strings.Split(baseVersion, ".")
-		if len(parts) >= 1 {
-			majorVersion := parts[0]
-			var bestVersion string
-			for v := range availableVersions {
-				vParts := strings.Split(v, ".")
-				if len(vParts) >= 1 && vParts[0] == majorVersion {
-					if bestVersion == "" || pm.compareVersions(v, bestVersion) > 0 {
-						bestVersion = v
-					}
-				}
-			}
-			return bestVersion
+func resolveVersionRange(versionRange string, availableVersions map[string]PackageInfo) string {
+	var bestVersion string
+	for v := range availableVersions {
+		if matchesVersionRange(v, versionRange) && (bestVersion == "" || compareVersions(v, bestVersion) > 0) {
+			bestVersion = v
 		}
 	}
+	return bestVersion
+}
 
-	if strings.HasPrefix(version, "~") {
-		baseVersion := strings.TrimPrefix(version, "~")
-		parts := strings.Split(baseVersion, ".")
-		if len(parts) >= 2 {
-			majorMinor := parts[0] + "." + parts[1]
-			var bestVersion string
-			for v := range availableVersions {
-				if strings.HasPrefix(v, majorMinor+".") {
-					if bestVersion == "" || pm.compareVersions(v, bestVersion) > 0 {
-						bestVersion = v
-					}
-				}
-			}
-			return bestVersion
-		}
+func satisfiesRange(version, versionRange string) bool {
+	versionRange = strings.TrimSpace(versionRange)
+
+	switch versionRange {
+	case "", "*", "x", "latest":
+		return true
 	}
 
-	if _, exists := availableVersions[version]; exists {
-		return version
+	if strings.Contains(versionRange, ":") || strings.Contains(versionRange, "/") {
+		return true
 	}
 
-	return ""
+	return matchesVersionRange(version, versionRange)
 }
 
-func (pm *PackageManager) compareVersions(v1, v2 string) int {
-	parts1 := strings.Split(v1, ".")
-	parts2 := strings.Split(v2, ".")
-
-	maxLen := len(parts1)
-	if len(parts2) > maxLen {
-		maxLen = len(parts2)
-	}
-
-	for i := 0; i < maxLen; i++ {
-		var p1, p2 int
-		if i < len(parts1) {
-			fmt.Sscanf(parts1[i], "%d", &p1)
+func matchesVersionRange(version, versionRange string) bool {
+	for _, part := range strings.Split(versionRange, "||") {
+		part = strings.TrimSpace(part)
+		if r, ok := parseSemverRange(part); ok {
+			if parsed, err := parseSemver(version); err == nil && r.contains(parsed) {
+				return true
+			}
+			continue
 		}
-		if i < len(parts2) {
-			fmt.Sscanf(parts2[i], "%d", &p2)
-		}
-
-		if p1 > p2 {
-			return 1
-		} else if p1 < p2 {
-			return -1
+		if part != "" && strings.TrimPrefix(strings.TrimPrefix(part, "="), "v") == version {
+			return true
 		}
 	}
-
-	return 0
+	return false
 }
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func packageTar(t testing.TB, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := 0; i+1 < len(files); i += 2 {
+		header := &tar.Header{Name: "package/" + files[i], Mode: 0644, Size: int64(len(files[i+1])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func integrityOf(data []byte) string {
+	hasher := newIntegrityHasher()
+	hasher.Write(data)
+	return hasher.Integrity()
+}
+
+func TestExtractPackageDetectsCaseCollisions(t *testing.T) {
+	events := &jsonReporter{}
+	previous := reporter
+	reporter = events
+	defer func() { reporter = previous }()
+
+	dest := t.TempDir()
+	data := packageTar(t, "README.md", "upper", "readme.md", "lower")
+	err := extractPackage(tar.NewReader(bytes.NewReader(data)), dest, 1)
+
+	if isCaseInsensitiveDir(dest) {
+		if err == nil {
+			t.Fatal("expected an error for colliding paths on a case-insensitive filesystem")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("extractPackage: %v", err)
+	}
+	if len(events.events) != 1 || events.events[0].Type != "warning" {
+		t.Fatalf("expected one collision warning, got %+v", events.events)
+	}
+	for name, want := range map[string]string{"README.md": "upper", "readme.md": "lower"} {
+		if got, err := os.ReadFile(filepath.Join(dest, name)); err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+}
+
+func TestFetchRejectsTamperedCacheEntry(t *testing.T) {
+	tarball := gzipBytes(t, packageTar(t, "package.json", `{"name":"left-pad","version":"1.3.0"}`))
+	cache := &Cache{cacheDir: t.TempDir()}
+	if err := os.WriteFile(cache.getPackagePath("left-pad", "1.3.0")+tarballExt, tarball, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lockFile := newLockFile()
+	lockFile.Packages["left-pad@1.3.0"] = LockPackage{Name: "left-pad", Version: "1.3.0", Integrity: integrityOf(tarball)}
+	pm := &PackageManager{nodeModulesPath: t.TempDir(), cache: cache, frozenLock: lockFile}
+	pkgInfo := &PackageInfo{Name: "left-pad", Version: "1.3.0"}
+
+	if err := cache.storeIntegrity("left-pad", "1.3.0", integrityOf([]byte("tampered"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pm.Fetch("left-pad", pkgInfo); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("Fetch with a tampered cache entry = %v, want an integrity mismatch", err)
+	}
+
+	if err := cache.storeIntegrity("left-pad", "1.3.0", integrityOf(tarball)); err != nil {
+		t.Fatal(err)
+	}
+	if cached, err := pm.Fetch("left-pad", pkgInfo); err != nil || !cached {
+		t.Fatalf("Fetch with a matching cache entry = %v, %v; want a cache hit", cached, err)
+	}
+}
+
+func testDownloadManager(t *testing.T) *PackageManager {
+	t.Helper()
+	previous := reporter
+	reporter = &jsonReporter{}
+	t.Cleanup(func() { reporter = previous })
+
+	return &PackageManager{
+		cache:         &Cache{cacheDir: t.TempDir()},
+		httpClient:    http.DefaultClient,
+		fetchAttempts: 2,
+	}
+}
+
+func TestDownloadAndExtractRetriesTruncatedStream(t *testing.T) {
+	tarball := gzipBytes(t, packageTar(t, "package.json", `{"name":"left-pad","version":"1.3.0"}`, "index.js", strings.Repeat("module.exports = 1\n", 512)))
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Write(tarball[:len(tarball)/2])
+			w.(http.Flusher).Flush()
+			return
+		}
+		w.Write(tarball)
+	}))
+	defer server.Close()
+
+	pm := testDownloadManager(t)
+	dest := filepath.Join(t.TempDir(), "left-pad")
+	pkgInfo := &PackageInfo{Name: "left-pad", Version: "1.3.0", Dist: DistInfo{Tarball: server.URL + "/left-pad.tgz", Integrity: integrityOf(tarball)}}
+
+	if err := pm.downloadAndExtract(pkgInfo, dest, ""); err != nil {
+		t.Fatalf("downloadAndExtract: %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("made %d requests, want 2", got)
+	}
+	if !fileExists(filepath.Join(dest, "index.js")) {
+		t.Error("index.js was not extracted")
+	}
+}
+
+func TestDownloadAndExtractDoesNotRetryInvalidArchive(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("<html>not a tarball</html>"))
+	}))
+	defer server.Close()
+
+	pm := testDownloadManager(t)
+	pkgInfo := &PackageInfo{Name: "left-pad", Version: "1.3.0", Dist: DistInfo{Tarball: server.URL + "/left-pad.tgz"}}
+
+	if err := pm.downloadAndExtract(pkgInfo, filepath.Join(t.TempDir(), "left-pad"), ""); err == nil {
+		t.Fatal("expected an error for a body that is not a gzip stream")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
+
+func TestDownloadAndExtractChecksContentLength(t *testing.T) {
+	tarball := gzipBytes(t, packageTar(t, "package.json", `{"name":"left-pad","version":"1.3.0"}`))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(tarball)+100))
+		w.Write(tarball)
+	}))
+	defer server.Close()
+
+	pm := testDownloadManager(t)
+	dest := filepath.Join(t.TempDir(), "left-pad")
+	pkgInfo := &PackageInfo{Name: "left-pad", Version: "1.3.0", Dist: DistInfo{Tarball: server.URL + "/left-pad.tgz"}}
+
+	err := pm.downloadAndExtractOnce(pkgInfo.Dist.Tarball, pkgInfo, dest, "")
+	var truncatedErr *truncatedDownloadError
+	if !errors.As(err, &truncatedErr) || !strings.Contains(err.Error(), strconv.Itoa(len(tarball)+100)) {
+		t.Fatalf("downloadAndExtractOnce = %v, want a truncated download of %d bytes", err, len(tarball)+100)
+	}
+	if fileExists(dest) {
+		t.Error("a short download was installed")
+	}
+}
+
+func TestRegistryMetadataFetchedOnceWithoutDiskCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"name":"left-pad","dist-tags":{"latest":"1.3.0"},"versions":{"1.3.0":{"name":"left-pad","version":"1.3.0"}}}`))
+	}))
+	defer server.Close()
+
+	pm := testDownloadManager(t)
+	pm.metadata = newMetadataCache("")
+
+	for i := 0; i < 3; i++ {
+		registryResp, err := pm.fetchRegistryResponseFrom(server.URL, "left-pad")
+		if err != nil {
+			t.Fatalf("fetchRegistryResponseFrom: %v", err)
+		}
+		if registryResp.DistTags["latest"] != "1.3.0" {
+			t.Errorf("latest = %q, want 1.3.0", registryResp.DistTags["latest"])
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type packumentIndex struct {
+	DistTags map[string]string
+	Names    []string
+	Kept     map[string]PackageInfo
+	Time     map[string]string
+}
+
+func decodePackumentIndex(r io.Reader, keep func(version string, distTags map[string]string) bool) (*packumentIndex, error) {
+	index := &packumentIndex{
+		DistTags: make(map[string]string),
+		Kept:     make(map[string]PackageInfo),
+	}
+
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch token {
+		case "dist-tags":
+			if err := decoder.Decode(&index.DistTags); err != nil {
+				return nil, err
+			}
+		case "versions":
+			if err := index.decodeVersions(decoder, keep); err != nil {
+				return nil, err
+			}
+		case "time":
+			if err := decoder.Decode(&index.Time); err != nil {
+				return nil, err
+			}
+		default:
+			if err := skipJSONValue(decoder); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return index, expectDelim(decoder, '}')
+}
+
+func (index *packumentIndex) decodeVersions(decoder *json.Decoder, keep func(version string, distTags map[string]string) bool) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		version, _ := token.(string)
+		index.Names = append(index.Names, version)
+
+		if !keep(version, index.DistTags) {
+			if err := skipJSONValue(decoder); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var pkgInfo PackageInfo
+		if err := decoder.Decode(&pkgInfo); err != nil {
+			return fmt.Errorf("version %s: %v", version, err)
+		}
+		index.Kept[version] = pkgInfo
+	}
+
+	return expectDelim(decoder, '}')
+}
+
+func (index *packumentIndex) versionStubs() map[string]PackageInfo {
+	stubs := make(map[string]PackageInfo, len(index.Names))
+	for _, version := range index.Names {
+		stubs[version] = PackageInfo{Version: version}
+	}
+	return stubs
+}
+
+func (index *packumentIndex) publishedBefore(packageName string, cutoff time.Time) error {
+	if len(index.Time) == 0 {
+		return fmt.Errorf("registry does not report publish times for %s, cannot apply --before", packageName)
+	}
+
+	names := index.Names[:0]
+	kept := make(map[string]bool)
+	for _, version := range index.Names {
+		published, err := time.Parse(time.RFC3339, index.Time[version])
+		if err != nil || published.After(cutoff) {
+			delete(index.Kept, version)
+			continue
+		}
+		names = append(names, version)
+		kept[version] = true
+	}
+	index.Names = names
+
+	for tag, version := range index.DistTags {
+		if !kept[version] {
+			delete(index.DistTags, tag)
+		}
+	}
+	if _, ok := index.DistTags["latest"]; !ok {
+		latest := ""
+		for _, version := range index.Names {
+			if !strings.Contains(version, "-") && (latest == "" || compareVersions(version, latest) > 0) {
+				latest = version
+			}
+		}
+		if latest != "" {
+			index.DistTags["latest"] = latest
+		}
+	}
+	return nil
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s, got %v", delim, token)
+	}
+	return nil
+}
+
+func skipJSONValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
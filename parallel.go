@@ -1,26 +1,65 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
-
-	"github.com/fatih/color"
 )
 
 type PackageJob struct {
 	Name         string
 	Version      string
+	RegistryName string
 	IsDev        bool
+	Optional     bool
+	SaveExact    bool
 	OriginalSpec string
 }
 
 type PackageResult struct {
 	Job              PackageJob
 	InstalledVersion string
+	Resolved         string
+	Integrity        string
 	Error            error
 	FromCache        bool
+	Timing           packageTiming
+}
+
+type packageInstallError struct {
+	Package string
+	Err     error
+}
+
+func (e *packageInstallError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Package, e.Err)
+}
+
+func (e *packageInstallError) Unwrap() error {
+	return e.Err
+}
+
+type partialInstallError struct {
+	Failed []string
+	Total  int
+	Err    error
+}
+
+func (e *partialInstallError) Error() string {
+	return fmt.Sprintf("%d of %d packages failed to install: %s", len(e.Failed), e.Total, strings.Join(e.Failed, ", "))
+}
+
+func (e *partialInstallError) Unwrap() error {
+	return e.Err
+}
+
+type fetchTask struct {
+	result  PackageResult
+	pkgInfo *PackageInfo
 }
 
 type ParallelInstaller struct {
@@ -28,6 +67,13 @@ type ParallelInstaller struct {
 	lockFile   *LockFile
 	timer      *Timer
 	maxWorkers int
+	checkpoint *checkpointWriter
+}
+
+const maxConcurrency = 64
+
+func defaultConcurrency() int {
+	return min(max(runtime.NumCPU(), 4), 16)
 }
 
 func NewParallelInstaller(pm *PackageManager, lockFile *LockFile, timer *Timer) *ParallelInstaller {
@@ -35,7 +81,7 @@ func NewParallelInstaller(pm *PackageManager, lockFile *LockFile, timer *Timer)
 		pm:         pm,
 		lockFile:   lockFile,
 		timer:      timer,
-		maxWorkers: 4,
+		maxWorkers: pm.concurrency,
 	}
 }
 
@@ -44,19 +90,33 @@ func (pi *ParallelInstaller) InstallPackages(jobs []PackageJob, writeToPackageJS
 		return nil
 	}
 
+	pi.pm.directPackages = make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		pi.pm.directPackages[job.Name] = true
+	}
+
 	totalJobs := len(jobs)
 	jobChan := make(chan PackageJob, totalJobs)
+	fetchChan := make(chan fetchTask, totalJobs)
 	resultChan := make(chan PackageResult, totalJobs)
 
 
-	progressDone := make(chan bool)
-	go pi.showProgress(totalJobs, resultChan, progressDone)
+	emitProgress(ProgressEvent{Phase: "install", Total: totalJobs})
+
+	release := output.Claim()
+	defer release()
+
+	progressDone := make(chan []*packageInstallError, 1)
+	go pi.showProgress(totalJobs, resultChan, progressDone, writeToPackageJSON)
+
 
+	var resolveWG, fetchWG sync.WaitGroup
+	for i := 0; i < min(pi.maxWorkers, totalJobs); i++ {
+		resolveWG.Add(1)
+		go pi.resolveWorker(jobChan, fetchChan, resultChan, &resolveWG)
 
-	var wg sync.WaitGroup
-	for i := 0; i < pi.maxWorkers; i++ {
-		wg.Add(1)
-		go pi.worker(jobChan, resultChan, &wg)
+		fetchWG.Add(1)
+		go pi.fetchWorker(fetchChan, resultChan, &fetchWG)
 	}
 
 
@@ -67,27 +127,44 @@ func (pi *ParallelInstaller) InstallPackages(jobs []PackageJob, writeToPackageJS
 
 
 	go func() {
-		wg.Wait()
+		resolveWG.Wait()
+		close(fetchChan)
+		fetchWG.Wait()
 		close(resultChan)
 	}()
 
 
-	<-progressDone
+	failures := <-progressDone
+	if len(failures) == 0 {
+		return nil
+	}
 
-	return nil
+	failed := make([]string, 0, len(failures))
+	errs := make([]error, 0, len(failures))
+	for _, failure := range failures {
+		failed = append(failed, failure.Package)
+		errs = append(errs, failure)
+	}
+	return &partialInstallError{Failed: failed, Total: totalJobs, Err: errors.Join(errs...)}
 }
 
-func (pi *ParallelInstaller) showProgress(total int, results <-chan PackageResult, done chan<- bool) {
-	defer close(done)
+func (pi *ParallelInstaller) showProgress(total int, results <-chan PackageResult, done chan<- []*packageInstallError, writeToPackageJSON bool) {
+	var failures []*packageInstallError
+	defer func() {
+		done <- failures
+		close(done)
+	}()
 
 	completed := 0
 	failed := 0
 	cached := 0
 	downloaded := 0
-	var errors []error
+	skipped := 0
+	var errors []string
+	var finished []PackageResult
 
-	frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
-	frameIndex := 0
+	startTime := time.Now()
+	startBytes := pi.pm.transferred.bytes.Load()
 
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
@@ -96,68 +173,106 @@ func (pi *ParallelInstaller) showProgress(total int, results <-chan PackageResul
 		select {
 		case result, ok := <-results:
 			if !ok {
-
-				fmt.Print("\r                                                                \r")
-
-				if failed > 0 {
-					fmt.Printf(" %s %d/%d packages installed, %d failed\n",
-						color.YellowString("⚠"), completed, total, failed)
-					for _, err := range errors {
-						fmt.Printf("   %s\n", err)
+				if pi.pm.installPeers {
+					peerFailures := pi.pm.installMissingPeers(pi.lockFile)
+					for _, failure := range peerFailures {
+						failed++
+						errors = append(errors, failure.Error())
 					}
-				} else {
-					fmt.Printf(" %s All %d packages installed successfully!\n",
-						color.HiGreenString("✓"), completed)
+					failures = append(failures, peerFailures...)
 				}
 
+				reporter.Report(InstallEvent{
+					Type:       "summary",
+					Total:      total,
+					Installed:  completed,
+					Failed:     failed,
+					Cached:     cached,
+					Downloaded: downloaded,
+					Errors:     errors,
+				})
+				pi.pm.reportDependencyFailures()
+				if pi.pm.timings > 0 {
+					reportSlowestPackages(finished, pi.pm.timings)
+				}
+				if pi.checkpoint != nil {
+					pi.checkpoint.close()
+				}
 
 				bm := NewBinaryManager()
 				if err := bm.setupAllBinaries(); err != nil {
-					fmt.Printf(" %s Failed to setup some binaries: %v\n", color.YellowString("⚠"), err)
+					reportWarning("Failed to setup some binaries: %v", err)
 				}
 
+				reportPeerProblems(pi.pm.nodeModulesPath)
+				return
+			}
 
-				if completed > 0 {
-					fmt.Printf(" %s %d cached, %d downloaded\n",
-						color.MagentaString("→"),
-						cached,
-						downloaded)
+			finished = append(finished, result)
+			if result.Error == nil {
+				if version, err := validateVersion(result.InstalledVersion); err != nil {
+					result.Error = err
+				} else {
+					result.InstalledVersion = version
 				}
-				return
 			}
 
-			if result.Error != nil {
+			if result.Error != nil && result.Job.Optional {
+				skipped++
+				version := result.InstalledVersion
+				if version == "" {
+					version = result.Job.Version
+				}
+				reportWarning("Skipped optional dependency %s: %v", result.Job.Name, result.Error)
+				pi.lockFile.addSkippedOptional(result.Job.Name, version, result.Job.IsDev)
+				emitProgress(ProgressEvent{Phase: "skipped", Package: result.Job.Name, Completed: completed + failed + skipped, Total: total})
+			} else if result.Error != nil {
 				failed++
-				errors = append(errors, fmt.Errorf("%s: %v", result.Job.Name, result.Error))
+				failures = append(failures, &packageInstallError{Package: result.Job.Name, Err: result.Error})
+				errors = append(errors, fmt.Sprintf("%s: %v", result.Job.Name, result.Error))
+				reporter.Report(InstallEvent{Type: "failed", Package: result.Job.Name, Error: result.Error.Error()})
+				emitProgress(ProgressEvent{Phase: "failed", Package: result.Job.Name, Completed: completed + failed + skipped, Total: total})
 			} else {
 				completed++
+				eventType := "downloaded"
 				if result.FromCache {
 					cached++
+					eventType = "cached"
 				} else {
 					downloaded++
 				}
+				reporter.Report(InstallEvent{Type: eventType, Package: result.Job.Name, Version: result.InstalledVersion})
+				emitProgress(ProgressEvent{Phase: eventType, Package: result.Job.Name, Version: result.InstalledVersion, Completed: completed + failed + skipped, Total: total})
 
 
 				if err := pi.lockFile.addPackage(result.Job.Name, result.InstalledVersion, result.Job.OriginalSpec, result.Job.IsDev); err != nil {
 
 				}
+				pi.lockFile.setResolution(result.Job.Name, result.InstalledVersion, result.Resolved, result.Integrity)
+				if result.Job.Optional {
+					pi.lockFile.markOptional(result.Job.Name, result.InstalledVersion)
+				}
+				if pi.checkpoint != nil {
+					pi.checkpoint.record(result.Job.Name, result.InstalledVersion)
+				}
 
 
-				if result.Job.Name != "" {
-					updatePackageJSON(result.Job.Name, result.InstalledVersion, result.Job.IsDev)
+				if writeToPackageJSON && result.Job.Name != "" && pi.pm.frozenLock == nil {
+					if err := updatePackageJSON(result.Job.Name, result.Job.savedRange(result.InstalledVersion), result.Job.IsDev); err != nil {
+						reportWarning("Failed to update package.json: %v", err)
+					}
 				}
 			}
 
 		case <-ticker.C:
-			frame := frames[frameIndex%len(frames)]
-			fmt.Printf("\r %s Installing packages...  %d / %d  completed",
-				color.CyanString(frame), completed, total)
-			frameIndex++
+			finished := completed + failed + skipped
+			stats := newTransferStats(pi.pm.transferred.bytes.Load()-startBytes, time.Since(startTime), finished, total)
+			reporter.Progress(finished, total, stats)
 		}
 	}
 }
 
-func (pi *ParallelInstaller) worker(jobs <-chan PackageJob, results chan<- PackageResult, wg *sync.WaitGroup) {
+func (pi *ParallelInstaller) resolveWorker(jobs <-chan PackageJob, fetches chan<- fetchTask, results chan<- PackageResult, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for job := range jobs {
@@ -171,7 +286,36 @@ func (pi *ParallelInstaller) worker(jobs <-chan PackageJob, results chan<- Packa
 
 
 		existingVersion := pi.lockFile.getPackageVersion(job.Name)
-		if existingVersion != "" && isPackageInstalled(fmt.Sprintf("node_modules/%s", job.Name), existingVersion) {
+		if pi.pm.frozenLock != nil {
+			if existingVersion == "" {
+				result.Error = fmt.Errorf("%s is not in %s", job.Name, lockFileName())
+				results <- result
+				continue
+			}
+			version = existingVersion
+		}
+
+		if resumedVersion, ok := pi.pm.resumed[job.Name]; ok && pi.pm.isPackageInstalled(filepath.Join(pi.pm.nodeModulesPath, job.Name), resumedVersion) {
+			result.InstalledVersion = resumedVersion
+			result.FromCache = true
+			results <- result
+			continue
+		}
+
+		if pi.pm.onlyMissing {
+			if manifest, err := readInstalledManifest(filepath.Join(pi.pm.nodeModulesPath, job.Name)); err == nil && manifest.Version != "" {
+				result.InstalledVersion = manifest.Version
+				result.FromCache = true
+				pi.pm.InstallDependencies(job.Name, pi.lockFile)
+				results <- result
+				continue
+			}
+			if existingVersion != "" && satisfiesRange(existingVersion, version) {
+				version = existingVersion
+			}
+		}
+
+		if existingVersion != "" && pi.pm.isInstalled(filepath.Join(pi.pm.nodeModulesPath, job.Name), job.registryName(), existingVersion) {
 			result.InstalledVersion = existingVersion
 			result.FromCache = true
 			results <- result
@@ -179,32 +323,85 @@ func (pi *ParallelInstaller) worker(jobs <-chan PackageJob, results chan<- Packa
 		}
 
 
+		emitProgress(ProgressEvent{Phase: "resolve", Package: job.Name})
+
 		if pi.timer != nil {
 			pi.timer.Pause()
 		}
 
+		resolveStart := time.Now()
+		pkgInfo, err := pi.pm.Resolve(job.registryName(), version)
+		result.Timing.Resolve = time.Since(resolveStart)
+
+		if pi.timer != nil {
+			pi.timer.Resume()
+		}
+
+		if err != nil {
+			result.Error = err
+			results <- result
+			continue
+		}
+
+		if job.Optional {
+			if err := checkPlatform(pkgInfo); err != nil {
+				result.InstalledVersion = pkgInfo.Version
+				result.Error = err
+				results <- result
+				continue
+			}
+		}
+
+		if err := pi.pm.checkEngines(pkgInfo); err != nil {
+			result.InstalledVersion = pkgInfo.Version
+			result.Error = err
+			results <- result
+			continue
+		}
+
+		fetches <- fetchTask{result: result, pkgInfo: pkgInfo}
+	}
+}
+
+func (pi *ParallelInstaller) fetchWorker(fetches <-chan fetchTask, results chan<- PackageResult, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for task := range fetches {
+		result := task.result
+		job := result.Job
 
-		installedVersion, wasCached, err := pi.pm.Install(job.Name, version)
+		emitProgress(ProgressEvent{Phase: "fetch", Package: job.Name, Version: task.pkgInfo.Version})
+
+		if pi.timer != nil {
+			pi.timer.Pause()
+		}
+
+		fetchStart := time.Now()
+		wasCached, err := pi.pm.Fetch(job.Name, task.pkgInfo)
+		result.Timing.Fetch = time.Since(fetchStart)
 
 		if pi.timer != nil {
 			pi.timer.Resume()
 		}
 
+		result.InstalledVersion = task.pkgInfo.Version
 		if err != nil {
 			result.Error = err
 			results <- result
 			continue
 		}
 
-		result.InstalledVersion = installedVersion
+		result.Resolved = task.pkgInfo.Dist.Tarball
+		result.Integrity = distIntegrity(task.pkgInfo.Dist)
 		result.FromCache = wasCached
 
 
 		if !wasCached {
+			dependenciesStart := time.Now()
 			if err := pi.pm.InstallDependencies(job.Name, pi.lockFile); err != nil {
-
-				fmt.Printf(" %s Warning: Failed to install dependencies for %s: %v\n", color.YellowString("⚠"), job.Name, err)
+				reportWarning("Failed to install dependencies for %s: %v", job.Name, err)
 			}
+			result.Timing.Dependencies = time.Since(dependenciesStart)
 		}
 
 		results <- result
@@ -213,25 +410,121 @@ func (pi *ParallelInstaller) worker(jobs <-chan PackageJob, results chan<- Packa
 
 func (pi *ParallelInstaller) InstallFromSpecs(packageSpecs []string, isDev bool, writeToPackageJSON bool) error {
 	var jobs []PackageJob
+	var unpinned []string
 
-	for _, spec := range packageSpecs {
+	for _, spec := range dedupePackageSpecs(packageSpecs) {
 		name, version := parsePackageSpec(spec)
 		originalSpec := spec
 		if version == "latest" {
 			originalSpec = name
 		}
 
-		jobs = append(jobs, PackageJob{
-			Name:         name,
-			Version:      version,
-			IsDev:        isDev,
-			OriginalSpec: originalSpec,
-		})
+		job := newPackageJob(name, version, isDev, originalSpec)
+		if job.Version == "latest" && !strings.HasSuffix(spec, "@latest") {
+			unpinned = append(unpinned, spec)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if pi.pm.strictRanges && len(unpinned) > 0 {
+		return unpinnedError(unpinned)
 	}
 
 	return pi.InstallPackages(jobs, writeToPackageJSON)
 }
 
+func (pi *ParallelInstaller) InstallUpgrades(upgrades []UpgradeInfo) error {
+	jobs := make([]PackageJob, 0, len(upgrades))
+	for _, upgrade := range upgrades {
+		spec := fmt.Sprintf("%s@%s", upgrade.Name, upgrade.LatestVersion)
+		job := newPackageJob(upgrade.Name, upgrade.LatestVersion, upgrade.IsDev, spec)
+		job.SaveExact = upgrade.Pinned
+		jobs = append(jobs, job)
+	}
+	return pi.InstallPackages(jobs, true)
+}
+
+func dedupePackageSpecs(packageSpecs []string) []string {
+	var names []string
+	specsByName := make(map[string][]string)
+
+	for _, spec := range packageSpecs {
+		name, _ := parsePackageSpec(spec)
+		if _, ok := specsByName[name]; !ok {
+			names = append(names, name)
+		}
+		specsByName[name] = append(specsByName[name], spec)
+	}
+
+	deduped := make([]string, 0, len(names))
+	for _, name := range names {
+		specs := specsByName[name]
+		last := specs[len(specs)-1]
+		_, lastVersion := parsePackageSpec(last)
+
+		for _, spec := range specs {
+			if _, version := parsePackageSpec(spec); version != lastVersion {
+				reportWarning("%s was given more than once (%s); installing %s", name, strings.Join(specs, ", "), last)
+				break
+			}
+		}
+
+		deduped = append(deduped, last)
+	}
+
+	return deduped
+}
+
+func newPackageJob(name, version string, isDev bool, originalSpec string) PackageJob {
+	job := PackageJob{
+		Name:         name,
+		Version:      version,
+		IsDev:        isDev,
+		OriginalSpec: originalSpec,
+	}
+
+	if realName, realVersion, ok := parseAliasVersion(version); ok {
+		job.RegistryName = realName
+		job.Version = realVersion
+	}
+
+	return job
+}
+
+func (job PackageJob) registryName() string {
+	if job.RegistryName != "" {
+		return job.RegistryName
+	}
+	return job.Name
+}
+
+func (job PackageJob) savedRange(installedVersion string) string {
+	prefix := savePrefix()
+	if job.SaveExact {
+		prefix = ""
+	}
+	if job.RegistryName != "" {
+		return fmt.Sprintf("npm:%s@%s%s", job.RegistryName, prefix, installedVersion)
+	}
+	return prefix + installedVersion
+}
+
+func unpinnedError(specs []string) error {
+	return fmt.Errorf("strict ranges: %s must specify a version or range (e.g. %s@^1.0.0)", strings.Join(specs, ", "), specs[0])
+}
+
+func parseAliasVersion(version string) (string, string, bool) {
+	if !strings.HasPrefix(version, "npm:") {
+		return "", "", false
+	}
+
+	realName, realVersion := parsePackageSpec(strings.TrimPrefix(version, "npm:"))
+	if realName == "" {
+		return "", "", false
+	}
+	return realName, realVersion, true
+}
+
 func parsePackageSpec(packageSpec string) (string, string) {
 	if strings.HasPrefix(packageSpec, "@") {
 		parts := strings.SplitN(packageSpec, "@", 3)
@@ -242,7 +535,7 @@ func parsePackageSpec(packageSpec string) (string, string) {
 		}
 		return packageSpec, "latest"
 	} else {
-		parts := strings.Split(packageSpec, "@")
+		parts := strings.SplitN(packageSpec, "@", 2)
 		if len(parts) > 1 {
 			return parts[0], parts[1]
 		}
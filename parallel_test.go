@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewPackageJobParsesNpmAliases(t *testing.T) {
+	tests := []struct {
+		name         string
+		version      string
+		registryName string
+		wantVersion  string
+		installed    string
+		savedRange   string
+	}{
+		{"my-lodash", "npm:lodash@^4.17.0", "lodash", "^4.17.0", "4.17.21", "npm:lodash@^4.17.21"},
+		{"types-node", "npm:@types/node@20.1.0", "@types/node", "20.1.0", "20.1.0", "npm:@types/node@^20.1.0"},
+		{"latest-lodash", "npm:lodash", "lodash", "latest", "4.17.21", "npm:lodash@^4.17.21"},
+		{"lodash", "^4.17.0", "", "^4.17.0", "4.17.21", "^4.17.21"},
+	}
+
+	for _, tt := range tests {
+		job := newPackageJob(tt.name, tt.version, false, tt.name+"@"+tt.version)
+		if job.RegistryName != tt.registryName || job.Version != tt.wantVersion {
+			t.Errorf("newPackageJob(%q, %q) = registry %q version %q, want %q %q", tt.name, tt.version, job.RegistryName, job.Version, tt.registryName, tt.wantVersion)
+		}
+		if got := job.savedRange(tt.installed); got != tt.savedRange {
+			t.Errorf("savedRange for %s = %q, want %q", tt.name, got, tt.savedRange)
+		}
+	}
+}
+
+func TestDedupePackageSpecs(t *testing.T) {
+	events := &jsonReporter{}
+	previous := reporter
+	reporter = events
+	defer func() { reporter = previous }()
+
+	got := dedupePackageSpecs([]string{"lodash@4.17.20", "react", "@types/node@18", "lodash@4.17.21", "react", "@types/node@20"})
+	want := []string{"lodash@4.17.21", "react", "@types/node@20"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dedupePackageSpecs = %v, want %v", got, want)
+	}
+
+	var warned []string
+	for _, event := range events.events {
+		if event.Type == "warning" {
+			warned = append(warned, event.Message)
+		}
+	}
+	if len(warned) != 2 {
+		t.Errorf("expected warnings for lodash and @types/node only, got %v", warned)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type PeerProblem struct {
+	Package   string
+	Peer      string
+	Range     string
+	Installed string
+}
+
+func checkPeerDependencies(nodeModulesPath string) ([]PeerProblem, error) {
+	packages, err := listInstalledPackages(nodeModulesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []PeerProblem
+	for _, name := range packages {
+		packagePath := filepath.Join(nodeModulesPath, name)
+		manifest, err := readInstalledManifest(packagePath)
+		if err != nil {
+			continue
+		}
+
+		for _, peerName := range sortedKeys(manifest.PeerDependencies) {
+			peerRange := manifest.PeerDependencies[peerName]
+			optional := manifest.PeerDependenciesMeta[peerName].Optional
+
+			problem := PeerProblem{
+				Package: fmt.Sprintf("%s@%s", name, manifest.Version),
+				Peer:    peerName,
+				Range:   peerRange,
+			}
+
+			peerPath := resolveInstalledDependency(nodeModulesPath, packagePath, peerName)
+			if peerPath == "" {
+				if !optional {
+					problems = append(problems, problem)
+				}
+				continue
+			}
+
+			peerManifest, err := readInstalledManifest(peerPath)
+			if err != nil {
+				continue
+			}
+
+			if !satisfiesRange(peerManifest.Version, peerRange) {
+				problem.Installed = peerManifest.Version
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+func reportPeerProblems(nodeModulesPath string) {
+	problems, err := checkPeerDependencies(nodeModulesPath)
+	if err != nil {
+		return
+	}
+
+	for _, problem := range problems {
+		if problem.Installed == "" {
+			reportWarning("%s requires peer %s@%s, which is not installed", problem.Package, problem.Peer, problem.Range)
+		} else {
+			reportWarning("%s requires peer %s@%s, but %s is installed", problem.Package, problem.Peer, problem.Range, problem.Installed)
+		}
+	}
+}
+
+type peerRequest struct {
+	Name       string
+	Ranges     map[string]bool
+	RequiredBy []string
+}
+
+func missingPeers(nodeModulesPath string) ([]*peerRequest, error) {
+	problems, err := checkPeerDependencies(nodeModulesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make(map[string]*peerRequest)
+	for _, problem := range problems {
+		if problem.Installed != "" {
+			continue
+		}
+		request, ok := requests[problem.Peer]
+		if !ok {
+			request = &peerRequest{Name: problem.Peer, Ranges: make(map[string]bool)}
+			requests[problem.Peer] = request
+		}
+		request.Ranges[problem.Range] = true
+		request.RequiredBy = append(request.RequiredBy, fmt.Sprintf("%s (%s)", problem.Package, problem.Range))
+	}
+
+	missing := make([]*peerRequest, 0, len(requests))
+	for _, request := range requests {
+		sort.Strings(request.RequiredBy)
+		missing = append(missing, request)
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Name < missing[j].Name
+	})
+	return missing, nil
+}
+
+func (pm *PackageManager) resolvePeerVersion(request *peerRequest) (string, error) {
+	ranges := sortedSet(request.Ranges)
+	index, err := pm.fetchPackumentIndex(request.Name, func(version string, distTags map[string]string) bool {
+		return satisfiesAllRanges(version, ranges)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if latest := index.DistTags["latest"]; latest != "" {
+		if _, ok := index.Kept[latest]; ok {
+			return latest, nil
+		}
+	}
+
+	var best string
+	for version := range index.Kept {
+		if best == "" || compareVersions(version, best) > 0 {
+			best = version
+		}
+	}
+	if best == "" {
+		if len(ranges) > 1 {
+			return "", fmt.Errorf("conflicting peer ranges, no version satisfies all of: %s", strings.Join(request.RequiredBy, ", "))
+		}
+		return "", fmt.Errorf("no version satisfies %s (required by %s)", ranges[0], strings.Join(request.RequiredBy, ", "))
+	}
+	return best, nil
+}
+
+func (pm *PackageManager) installMissingPeers(lockFile *LockFile) []*packageInstallError {
+	var failures []*packageInstallError
+	attempted := make(map[string]bool)
+
+	for {
+		missing, err := missingPeers(pm.nodeModulesPath)
+		if err != nil {
+			return append(failures, &packageInstallError{Package: "peer dependencies", Err: err})
+		}
+
+		var pending []*peerRequest
+		for _, request := range missing {
+			if !attempted[request.Name] {
+				pending = append(pending, request)
+			}
+		}
+		if len(pending) == 0 {
+			return failures
+		}
+
+		for _, request := range pending {
+			attempted[request.Name] = true
+			if err := pm.installPeer(request, lockFile); err != nil {
+				failures = append(failures, &packageInstallError{Package: request.Name, Err: err})
+				if reporter.Human() {
+					output.Printf(" %s Could not install peer %s: %v\n", color.RedString("✗"), color.CyanString(request.Name), err)
+				} else {
+					reporter.Report(InstallEvent{Type: "failed", Package: request.Name, Message: "peer dependency", Error: err.Error()})
+				}
+			}
+		}
+	}
+}
+
+func (pm *PackageManager) installPeer(request *peerRequest, lockFile *LockFile) error {
+	version, err := pm.resolvePeerVersion(request)
+	if err != nil {
+		return err
+	}
+
+	pkgInfo, err := pm.installSimple(request.Name, version, false)
+	if err != nil {
+		return err
+	}
+	if err := lockFile.addPackage(request.Name, pkgInfo.Version, request.Name, false); err != nil {
+		return err
+	}
+	lockFile.setResolution(request.Name, pkgInfo.Version, pkgInfo.Dist.Tarball, distIntegrity(pkgInfo.Dist))
+	lockFile.markPeer(request.Name, pkgInfo.Version)
+	pm.InstallDependencies(request.Name, lockFile)
+
+	if reporter.Human() {
+		output.Printf(" %s Installed peer %s %s\n",
+			color.HiGreenString("✓"),
+			color.CyanString("%s@%s", request.Name, pkgInfo.Version),
+			color.HiBlackString("(required by %s)", strings.Join(request.RequiredBy, ", ")))
+	} else {
+		reporter.Report(InstallEvent{Type: "peer", Package: request.Name, Version: pkgInfo.Version, Message: "required by " + strings.Join(request.RequiredBy, ", ")})
+	}
+	return nil
+}
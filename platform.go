@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+type unsupportedPlatformError struct {
+	name     string
+	version  string
+	platform string
+}
+
+func (e *unsupportedPlatformError) Error() string {
+	return fmt.Sprintf("%s@%s does not support %s", e.name, e.version, e.platform)
+}
+
+func npmPlatform() string {
+	if runtime.GOOS == "windows" {
+		return "win32"
+	}
+	return runtime.GOOS
+}
+
+func npmArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x64"
+	case "386":
+		return "ia32"
+	}
+	return runtime.GOARCH
+}
+
+func checkPlatform(pkgInfo *PackageInfo) error {
+	if !matchesPlatformList(pkgInfo.OS, npmPlatform()) || !matchesPlatformList(pkgInfo.CPU, npmArch()) {
+		return &unsupportedPlatformError{
+			name:     pkgInfo.Name,
+			version:  pkgInfo.Version,
+			platform: npmPlatform() + "-" + npmArch(),
+		}
+	}
+	return nil
+}
+
+func matchesPlatformList(list []string, current string) bool {
+	if len(list) == 0 {
+		return true
+	}
+
+	allowed := false
+	hasAllowList := false
+	for _, entry := range list {
+		if strings.HasPrefix(entry, "!") {
+			if strings.TrimPrefix(entry, "!") == current {
+				return false
+			}
+			continue
+		}
+		hasAllowList = true
+		if entry == current {
+			allowed = true
+		}
+	}
+
+	return allowed || !hasAllowList
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type ProgressEvent struct {
+	Phase     string  `json:"phase"`
+	Package   string  `json:"package,omitempty"`
+	Version   string  `json:"version,omitempty"`
+	Completed int     `json:"completed,omitempty"`
+	Total     int     `json:"total,omitempty"`
+	Percent   float64 `json:"percent,omitempty"`
+	ElapsedMs int64   `json:"elapsedMs"`
+}
+
+type progressEmitter struct {
+	mu        sync.Mutex
+	encoder   *json.Encoder
+	startTime time.Time
+}
+
+var progressStream *progressEmitter
+
+func newProgressEmitter(w io.Writer) *progressEmitter {
+	return &progressEmitter{
+		encoder:   json.NewEncoder(w),
+		startTime: time.Now(),
+	}
+}
+
+func emitProgress(event ProgressEvent) {
+	if progressStream == nil {
+		return
+	}
+
+	if event.Total > 0 {
+		event.Percent = float64(event.Completed) * 100 / float64(event.Total)
+	}
+
+	progressStream.mu.Lock()
+	defer progressStream.mu.Unlock()
+
+	event.ElapsedMs = time.Since(progressStream.startTime).Milliseconds()
+	progressStream.encoder.Encode(event)
+}
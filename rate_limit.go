@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type rateLimiter struct {
+	bytesPerSecond int64
+	mu             sync.Mutex
+	next           time.Time
+}
+
+type rateLimitedReader struct {
+	reader  io.Reader
+	limiter *rateLimiter
+}
+
+var byteRateUnits = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"gib", 1 << 30}, {"gb", 1 << 30}, {"g", 1 << 30},
+	{"mib", 1 << 20}, {"mb", 1 << 20}, {"m", 1 << 20},
+	{"kib", 1 << 10}, {"kb", 1 << 10}, {"k", 1 << 10},
+	{"b", 1},
+}
+
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	return &rateLimiter{bytesPerSecond: bytesPerSecond}
+}
+
+func parseByteRate(value string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	s = strings.TrimSuffix(s, "/s")
+
+	multiplier := 1.0
+	for _, unit := range byteRateUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSuffix(s, unit.suffix)
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("invalid rate %q (expected something like 500KB/s or 2MB/s)", value)
+	}
+
+	bytesPerSecond := int64(amount * multiplier)
+	if bytesPerSecond < 1 {
+		return 0, fmt.Errorf("invalid rate %q (must be at least 1 byte per second)", value)
+	}
+	return bytesPerSecond, nil
+}
+
+func (rl *rateLimiter) chunkSize() int {
+	size := rl.bytesPerSecond / 10
+	if size < 512 {
+		size = 512
+	}
+	if size > 32*1024 {
+		size = 32 * 1024
+	}
+	return int(size)
+}
+
+func (rl *rateLimiter) wait(n int) {
+	rl.mu.Lock()
+	now := time.Now()
+	if rl.next.Before(now) {
+		rl.next = now
+	}
+	rl.next = rl.next.Add(time.Duration(int64(n) * int64(time.Second) / rl.bytesPerSecond))
+	delay := rl.next.Sub(now)
+	rl.mu.Unlock()
+
+	time.Sleep(delay)
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if size := r.limiter.chunkSize(); len(p) > size {
+		p = p[:size]
+	}
+
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.limiter.wait(n)
+	}
+	return n, err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"regexp"
+)
+
+const redactedSecret = "***"
+
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(://[^/\s:@]*:)[^/\s@]+@`), "${1}" + redactedSecret + "@"},
+	{regexp.MustCompile(`(://)[^/\s:@]+@`), "${1}" + redactedSecret + "@"},
+	{regexp.MustCompile(`(?i)(_authToken|_auth|_password|token|password|secret|apikey|api_key)(["']?\s*[=:]\s*["']?)[^\s"'&,;]+`), "${1}${2}" + redactedSecret},
+	{regexp.MustCompile(`(?i)(authorization:\s*)(bearer|basic|token)?\s*[^\s"',;]+`), "${1}${2} " + redactedSecret},
+	{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]{8,}`), "${1} " + redactedSecret},
+	{regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`), redactedSecret},
+}
+
+func redactSecrets(s string) string {
+	for _, secret := range secretPatterns {
+		s = secret.pattern.ReplaceAllString(s, secret.replacement)
+	}
+	return s
+}
+
+type redactingReporter struct {
+	Reporter
+}
+
+func (r *redactingReporter) Report(event InstallEvent) {
+	event.Message = redactSecrets(event.Message)
+	event.Error = redactSecrets(event.Error)
+	for i, err := range event.Errors {
+		event.Errors[i] = redactSecrets(err)
+	}
+	r.Reporter.Report(event)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+type registryError struct {
+	Kind       string
+	Host       string
+	StatusCode int
+	RetryAfter time.Duration
+	Err        error
+	message    string
+	hint       string
+}
+
+func (e *registryError) Error() string {
+	if e.hint == "" {
+		return e.message
+	}
+	return fmt.Sprintf("%s (%s)", e.message, e.hint)
+}
+
+func (e *registryError) Unwrap() error {
+	return e.Err
+}
+
+func requestHost(target string) string {
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Host == "" {
+		return target
+	}
+	return parsed.Host
+}
+
+func classifyRequestError(action, target string, err error) error {
+	host := requestHost(target)
+	e := &registryError{Host: host, Err: err}
+
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "proxyconnect":
+		e.Kind = "proxy"
+		e.message = fmt.Sprintf("%s: could not connect through the proxy to %s: %v", action, host, opErr.Err)
+		e.hint = "check HTTPS_PROXY/HTTP_PROXY"
+	case errors.As(err, &dnsErr):
+		e.Kind = "dns"
+		e.message = fmt.Sprintf("%s: could not resolve %s", action, dnsErr.Name)
+		e.hint = "check your network connection and the registry URL"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		e.Kind = "refused"
+		e.message = fmt.Sprintf("%s: connection to %s refused", action, host)
+		e.hint = "check the registry URL and port, and that the registry is running"
+	case errors.Is(err, syscall.ECONNRESET):
+		e.Kind = "reset"
+		e.message = fmt.Sprintf("%s: connection to %s was reset", action, host)
+		e.hint = "check your network or proxy"
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &hostnameErr):
+		e.Kind = "tls"
+		e.message = fmt.Sprintf("%s: TLS certificate of %s is not trusted: %v", action, host, err)
+		e.hint = "check the registry URL, or install your proxy's CA certificate"
+	case errors.As(err, &recordErr):
+		e.Kind = "tls"
+		e.message = fmt.Sprintf("%s: TLS handshake with %s failed", action, host)
+		e.hint = "the server does not speak HTTPS; check the registry URL scheme"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		e.Kind = "timeout"
+		e.message = fmt.Sprintf("%s: %s did not respond in time", action, host)
+		e.hint = "check your network or proxy, or raise --fetch-timeout/--download-timeout"
+	default:
+		e.Kind = "network"
+		e.message = fmt.Sprintf("%s: %v", action, err)
+		e.hint = "check your network connection"
+	}
+
+	return e
+}
+
+func registryStatusError(target string, statusCode int, retryAfter time.Duration) error {
+	host := requestHost(target)
+	e := &registryError{Kind: "status", Host: host, StatusCode: statusCode, RetryAfter: retryAfter}
+	e.message = fmt.Sprintf("npm registry error: status %d from %s", statusCode, host)
+
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		e.hint = "the registry requires authentication or denied access; check your registry credentials"
+	case statusCode == http.StatusTooManyRequests:
+		e.hint = "the registry is rate limiting requests; wait and retry"
+	case statusCode == http.StatusProxyAuthRequired:
+		e.hint = "your proxy requires authentication; check HTTPS_PROXY/HTTP_PROXY"
+	case statusCode >= 500:
+		e.hint = "the registry is having problems; try again later or use another registry"
+	}
+
+	return e
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+type InstallEvent struct {
+	Type       string         `json:"type"`
+	Package    string         `json:"package,omitempty"`
+	Version    string         `json:"version,omitempty"`
+	Message    string         `json:"message,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Total      int            `json:"total,omitempty"`
+	Installed  int            `json:"installed,omitempty"`
+	Failed     int            `json:"failed,omitempty"`
+	Cached     int            `json:"cached,omitempty"`
+	Downloaded int            `json:"downloaded,omitempty"`
+	Errors     []string       `json:"errors,omitempty"`
+	ElapsedMs  int64          `json:"elapsedMs,omitempty"`
+	Timing     *PackageTiming `json:"timing,omitempty"`
+}
+
+type Reporter interface {
+	Report(event InstallEvent)
+	Progress(completed, total int, stats transferStats)
+	Human() bool
+}
+
+const statusLineTicks = 50
+
+var reporter Reporter = &redactingReporter{&defaultReporter{}}
+
+func newReporter(name string) (Reporter, error) {
+	switch name {
+	case "", "default":
+		return &redactingReporter{&defaultReporter{}}, nil
+	case "silent":
+		return &redactingReporter{&silentReporter{}}, nil
+	case "json":
+		return &redactingReporter{&jsonReporter{}}, nil
+	case "ndjson":
+		return &redactingReporter{&ndjsonReporter{encoder: json.NewEncoder(os.Stdout)}}, nil
+	}
+	return nil, fmt.Errorf("unknown reporter: %s (expected default, silent, json or ndjson)", name)
+}
+
+func reportWarning(format string, args ...interface{}) {
+	reporter.Report(InstallEvent{Type: "warning", Message: fmt.Sprintf(format, args...)})
+}
+
+type defaultReporter struct {
+	frameIndex   int
+	lastReported int
+}
+
+func (r *defaultReporter) Human() bool {
+	return true
+}
+
+func (r *defaultReporter) Report(event InstallEvent) {
+	switch event.Type {
+	case "info":
+		output.Printf(" %s %s\n", color.CyanString("ℹ"), event.Message)
+	case "warning":
+		output.Printf(" %s %s\n", color.YellowString("⚠"), event.Message)
+	case "retry":
+		output.Printf(" %s Retrying %s: %s\n", color.YellowString("↻"), event.Package, event.Message)
+	case "summary":
+		if event.Failed > 0 {
+			output.Printf(" %s %d/%d packages installed, %d failed\n",
+				color.YellowString("⚠"), event.Installed, event.Total, event.Failed)
+			for _, err := range event.Errors {
+				output.Printf("   %s\n", err)
+			}
+		} else {
+			output.Printf(" %s All %d packages installed successfully!\n",
+				color.HiGreenString("✓"), event.Installed)
+		}
+
+		if event.Installed > 0 {
+			output.Printf(" %s %d cached, %d downloaded\n",
+				color.MagentaString("→"),
+				event.Cached,
+				event.Downloaded)
+		}
+	case "done":
+		output.Printf(" %s Done in %s\n",
+			color.HiGreenString("✓"),
+			color.HiBlackString(formatDuration(time.Duration(event.ElapsedMs)*time.Millisecond)))
+	}
+}
+
+func (r *defaultReporter) Progress(completed, total int, stats transferStats) {
+	if progressDisabled {
+		return
+	}
+
+	frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+	if !interactiveOutput {
+		if r.frameIndex%statusLineTicks == 0 && completed != r.lastReported {
+			output.Printf(" Installing packages...  %d / %d  completed%s\n", completed, total, stats)
+			r.lastReported = completed
+		}
+		r.frameIndex++
+		return
+	}
+
+	frame := frames[r.frameIndex%len(frames)]
+	output.Status(fmt.Sprintf(" %s Installing packages...  %d / %d  completed%s",
+		color.CyanString(frame), completed, total, color.HiBlackString(stats.String())))
+	r.frameIndex++
+}
+
+type silentReporter struct{}
+
+func (r *silentReporter) Human() bool {
+	return false
+}
+
+func (r *silentReporter) Report(event InstallEvent) {}
+
+func (r *silentReporter) Progress(completed, total int, stats transferStats) {}
+
+type jsonReporter struct {
+	mu     sync.Mutex
+	events []InstallEvent
+}
+
+func (r *jsonReporter) Human() bool {
+	return false
+}
+
+func (r *jsonReporter) Report(event InstallEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+	if event.Type != "done" {
+		return
+	}
+
+	data, err := json.MarshalIndent(struct {
+		Events []InstallEvent `json:"events"`
+	}{r.events}, "", "  ")
+	if err != nil {
+		return
+	}
+	fmt.Println(string(data))
+}
+
+func (r *jsonReporter) Progress(completed, total int, stats transferStats) {}
+
+type ndjsonReporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func (r *ndjsonReporter) Human() bool {
+	return false
+}
+
+func (r *ndjsonReporter) Report(event InstallEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.encoder.Encode(event)
+}
+
+func (r *ndjsonReporter) Progress(completed, total int, stats transferStats) {}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFetchAttempts = 3
+	retryBaseDelay       = 500 * time.Millisecond
+	retryMaxDelay        = 30 * time.Second
+)
+
+type retriesExhaustedError struct {
+	err      error
+	attempts int
+}
+
+func (e *retriesExhaustedError) Error() string {
+	return fmt.Sprintf("%v (gave up after %d attempts)", e.err, e.attempts)
+}
+
+func (e *retriesExhaustedError) Unwrap() error {
+	return e.err
+}
+
+func retryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+func isRetryableError(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return retryableStatus(statusErr.StatusCode)
+	}
+
+	var regErr *registryError
+	if errors.As(err, &regErr) {
+		if regErr.Kind == "status" {
+			return retryableStatus(regErr.StatusCode)
+		}
+		return regErr.Kind != "tls"
+	}
+
+	var truncatedErr *truncatedDownloadError
+	return errors.As(err, &truncatedErr)
+}
+
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+func retryDelay(attempt int, err error) time.Duration {
+	var retryAfter time.Duration
+	var statusErr *httpStatusError
+	var regErr *registryError
+	if errors.As(err, &statusErr) {
+		retryAfter = statusErr.RetryAfter
+	} else if errors.As(err, &regErr) {
+		retryAfter = regErr.RetryAfter
+	}
+	if retryAfter > 0 {
+		return min(retryAfter, retryMaxDelay)
+	}
+
+	delay := min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (pm *PackageManager) retry(packageName string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isRetryableError(err) {
+			return err
+		}
+		if attempt >= pm.fetchAttempts {
+			if attempt > 1 {
+				return &retriesExhaustedError{err: err, attempts: attempt}
+			}
+			return err
+		}
+
+		delay := retryDelay(attempt, err)
+		reporter.Report(InstallEvent{
+			Type:    "retry",
+			Package: packageName,
+			Message: fmt.Sprintf("attempt %d/%d in %s: %v", attempt+1, pm.fetchAttempts, delay.Round(10*time.Millisecond), err),
+		})
+		time.Sleep(delay)
+	}
+}
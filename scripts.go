@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/fatih/color"
+)
+
+var scriptsDisabled = false
+
+type scriptExecution struct {
+	Package string
+	Version string
+	Event   string
+	Script  string
+	Failed  bool
+}
+
+var executedScripts []scriptExecution
+
+func runWithHooks(command string, handler func()) error {
+	checkPinnedNode()
+	checkProjectEngines()
+
+	err := runProjectScript("pre" + command)
+	if err == nil {
+		handler()
+		err = runProjectScript("post" + command)
+	}
+
+	if command == "install" {
+		printScriptSummary()
+	}
+	return err
+}
+
+func runProjectScript(name string) error {
+	manifestPath := projectManifestPath()
+	if scriptsDisabled || !fileExists(manifestPath) {
+		return nil
+	}
+
+	pkg, err := loadPackageJSON(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	script, ok := pkg.Scripts[name]
+	if !ok || script == "" {
+		return nil
+	}
+
+	cmd := scriptCommand(script)
+	cmd.Dir = filepath.Dir(manifestPath)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	if reporter.Human() {
+		fmt.Printf(" %s Running %s: %s\n", color.CyanString("▶"), color.CyanString(name), color.HiBlackString(script))
+	} else {
+		reporter.Report(InstallEvent{Type: "info", Package: pkg.Name, Version: pkg.Version, Message: fmt.Sprintf("Running %s: %s", name, script)})
+		cmd.Stdout = os.Stderr
+	}
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"PATH="+scriptPath(),
+		"npm_lifecycle_event="+name,
+		"npm_lifecycle_script="+script,
+		"npm_package_name="+pkg.Name,
+		"npm_package_version="+pkg.Version,
+	)
+
+	err = cmd.Run()
+	executedScripts = append(executedScripts, scriptExecution{
+		Package: pkg.Name,
+		Version: pkg.Version,
+		Event:   name,
+		Script:  script,
+		Failed:  err != nil,
+	})
+	if err != nil {
+		return fmt.Errorf("%s script failed: %v", name, err)
+	}
+	return nil
+}
+
+func projectManifestPath() string {
+	if path := flagValue("--manifest"); path != "" {
+		return path
+	}
+	return "package.json"
+}
+
+func printScriptSummary() {
+	if len(executedScripts) == 0 {
+		return
+	}
+
+	if !reporter.Human() {
+		for _, execution := range executedScripts {
+			event := InstallEvent{Type: "script", Package: execution.Package, Version: execution.Version, Message: execution.Event + ": " + execution.Script}
+			if execution.Failed {
+				event.Error = "script failed"
+			}
+			reporter.Report(event)
+		}
+		return
+	}
+
+	fmt.Printf(" %s %d lifecycle script(s) ran:\n", color.CyanString("▶"), len(executedScripts))
+	for _, execution := range executedScripts {
+		name := execution.Package
+		if name == "" {
+			name = "(root)"
+		}
+		status := ""
+		if execution.Failed {
+			status = color.RedString(" (failed)")
+		}
+		fmt.Printf("   %s %s %s%s\n", color.CyanString(name), color.YellowString(execution.Event), color.HiBlackString(execution.Script), status)
+	}
+}
+
+func scriptCommand(script string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", script)
+	}
+	return exec.Command("sh", "-c", script)
+}
+
+func scriptPath() string {
+	path := os.Getenv("PATH")
+	if pinnedNodeBinDir != "" {
+		path = pinnedNodeBinDir + string(os.PathListSeparator) + path
+	}
+
+	binPath, err := filepath.Abs(filepath.Join(nodeModulesDir(), ".bin"))
+	if err != nil {
+		return path
+	}
+	return binPath + string(os.PathListSeparator) + path
+}
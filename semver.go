@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type semver struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+}
+
+func parseSemver(version string) (semver, error) {
+	var v semver
+
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(version), "="), "v")
+	trimmed, _, _ = strings.Cut(trimmed, "+")
+	core, prerelease, hasPrerelease := strings.Cut(trimmed, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid version %q: expected major.minor.patch", version)
+	}
+	numbers := make([]uint64, 3)
+	for i, part := range parts {
+		if !isNumericIdentifier(part) {
+			return v, fmt.Errorf("invalid version %q: %q is not a number", version, part)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid version %q: %v", version, err)
+		}
+		numbers[i] = n
+	}
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+
+	if hasPrerelease {
+		for _, identifier := range strings.Split(prerelease, ".") {
+			if identifier == "" {
+				return v, fmt.Errorf("invalid version %q: empty prerelease identifier", version)
+			}
+			if isDigits(identifier) && len(identifier) > 1 && identifier[0] == '0' {
+				return v, fmt.Errorf("invalid version %q: prerelease identifier %q has a leading zero", version, identifier)
+			}
+			v.Prerelease = append(v.Prerelease, identifier)
+		}
+	}
+
+	return v, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumericIdentifier(s string) bool {
+	return isDigits(s) && (len(s) == 1 || s[0] != '0')
+}
+
+func compareSemver(a, b string) (int, error) {
+	va, err := parseSemver(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseSemver(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.compare(vb), nil
+}
+
+func (v semver) compare(other semver) int {
+	for _, pair := range [][2]uint64{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if cmp := comparePrereleaseIdentifier(v.Prerelease[i], other.Prerelease[i]); cmp != 0 {
+			return cmp
+		}
+	}
+	switch {
+	case len(v.Prerelease) < len(other.Prerelease):
+		return -1
+	case len(v.Prerelease) > len(other.Prerelease):
+		return 1
+	}
+	return 0
+}
+
+func comparePrereleaseIdentifier(a, b string) int {
+	aNumeric, bNumeric := isDigits(a), isDigits(b)
+	switch {
+	case aNumeric && bNumeric:
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+type semverComparator struct {
+	op      string
+	version semver
+}
+
+type semverRange []semverComparator
+
+type partialVersion struct {
+	numbers    []uint64
+	prerelease []string
+}
+
+func parsePartialVersion(spec string) (partialVersion, bool) {
+	var p partialVersion
+
+	spec = strings.TrimPrefix(strings.TrimSpace(spec), "v")
+	spec, _, _ = strings.Cut(spec, "+")
+	core, prerelease, hasPrerelease := strings.Cut(spec, "-")
+
+	for _, part := range strings.Split(core, ".") {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		if !isDigits(part) {
+			return p, false
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return p, false
+		}
+		p.numbers = append(p.numbers, n)
+	}
+	if len(p.numbers) > 3 || (hasPrerelease && len(p.numbers) < 3) {
+		return p, false
+	}
+	if hasPrerelease {
+		p.prerelease = strings.Split(prerelease, ".")
+	}
+	return p, true
+}
+
+func (p partialVersion) full() bool {
+	return len(p.numbers) == 3
+}
+
+func (p partialVersion) lower() semver {
+	numbers := append(append([]uint64{}, p.numbers...), 0, 0, 0)
+	return semver{Major: numbers[0], Minor: numbers[1], Patch: numbers[2], Prerelease: p.prerelease}
+}
+
+func (p partialVersion) upper(operator string) *semver {
+	v := p.lower()
+	switch {
+	case len(p.numbers) == 0:
+		return nil
+	case operator == "^" && (v.Major > 0 || len(p.numbers) == 1):
+		return &semver{Major: v.Major + 1}
+	case operator == "^" && (v.Minor > 0 || len(p.numbers) == 2):
+		return &semver{Minor: v.Minor + 1}
+	case operator == "^":
+		return &semver{Patch: v.Patch + 1}
+	case len(p.numbers) == 1:
+		return &semver{Major: v.Major + 1}
+	case operator == "~" || len(p.numbers) == 2:
+		return &semver{Major: v.Major, Minor: v.Minor + 1}
+	}
+	return &semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+var rangeOperators = []string{">=", "<=", ">", "<", "=", "^", "~"}
+
+func rangeTokens(spec string) []string {
+	var tokens []string
+	fields := strings.Fields(spec)
+	for i := 0; i < len(fields); i++ {
+		token := fields[i]
+		if slices.Contains(rangeOperators, token) && i+1 < len(fields) {
+			token += fields[i+1]
+			i++
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+func parseSemverRange(spec string) (semverRange, bool) {
+	tokens := rangeTokens(spec)
+	switch {
+	case len(tokens) == 0:
+		return nil, false
+	case len(tokens) == 1:
+		if _, err := parseSemver(tokens[0]); err == nil {
+			return nil, false
+		}
+	case len(tokens) == 3 && tokens[1] == "-":
+		return parseHyphenRange(tokens[0], tokens[2])
+	}
+
+	r := semverRange{}
+	for _, token := range tokens {
+		comparators, ok := parseComparator(token)
+		if !ok {
+			return nil, false
+		}
+		r = append(r, comparators...)
+	}
+	return r, true
+}
+
+func parseHyphenRange(from, to string) (semverRange, bool) {
+	lower, ok := parsePartialVersion(from)
+	if !ok {
+		return nil, false
+	}
+	upper, ok := parsePartialVersion(to)
+	if !ok {
+		return nil, false
+	}
+
+	r := semverRange{{op: ">=", version: lower.lower()}}
+	switch {
+	case upper.full():
+		r = append(r, semverComparator{op: "<=", version: upper.lower()})
+	case len(upper.numbers) > 0:
+		r = append(r, semverComparator{op: "<", version: *upper.upper("")})
+	}
+	return r, true
+}
+
+func parseComparator(token string) ([]semverComparator, bool) {
+	operator := ""
+	for _, candidate := range rangeOperators {
+		if strings.HasPrefix(token, candidate) {
+			operator, token = candidate, strings.TrimPrefix(token, candidate)
+			break
+		}
+	}
+
+	p, ok := parsePartialVersion(token)
+	if !ok {
+		return nil, false
+	}
+	none := []semverComparator{{op: "<", version: semver{}}}
+
+	switch operator {
+	case ">=":
+		return []semverComparator{{op: ">=", version: p.lower()}}, true
+	case "<":
+		if len(p.numbers) == 0 {
+			return none, true
+		}
+		return []semverComparator{{op: "<", version: p.lower()}}, true
+	case ">":
+		switch {
+		case len(p.numbers) == 0:
+			return none, true
+		case p.full():
+			return []semverComparator{{op: ">", version: p.lower()}}, true
+		}
+		return []semverComparator{{op: ">=", version: *p.upper("")}}, true
+	case "<=":
+		switch {
+		case len(p.numbers) == 0:
+			return nil, true
+		case p.full():
+			return []semverComparator{{op: "<=", version: p.lower()}}, true
+		}
+		return []semverComparator{{op: "<", version: *p.upper("")}}, true
+	case "", "=":
+		if p.full() {
+			return []semverComparator{{op: "=", version: p.lower()}}, true
+		}
+	}
+
+	comparators := []semverComparator{{op: ">=", version: p.lower()}}
+	if upper := p.upper(operator); upper != nil {
+		comparators = append(comparators, semverComparator{op: "<", version: *upper})
+	}
+	return comparators, true
+}
+
+func (r semverRange) contains(v semver) bool {
+	for _, c := range r {
+		cmp := v.compare(c.version)
+		switch {
+		case c.op == ">=" && cmp < 0,
+			c.op == ">" && cmp <= 0,
+			c.op == "<=" && cmp > 0,
+			c.op == "<" && cmp >= 0,
+			c.op == "=" && cmp != 0:
+			return false
+		}
+	}
+
+	if len(v.Prerelease) == 0 {
+		return true
+	}
+	for _, c := range r {
+		if len(c.version.Prerelease) > 0 && c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+func isVersionRange(spec string) bool {
+	if strings.Contains(spec, "||") {
+		return true
+	}
+	_, ok := parseSemverRange(spec)
+	return ok
+}
@@ -0,0 +1,129 @@
+package main
+
+import "testing"
+
+func TestCaretRangeOnZeroMajor(t *testing.T) {
+	tests := []struct {
+		spec    string
+		version string
+		want    bool
+	}{
+		{"^0.2.3", "0.2.3", true},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.2.3", "0.4.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"^0.2", "0.2.5", true},
+		{"^0.2", "0.3.0", false},
+		{"^0.0", "0.0.9", true},
+		{"^0.0", "0.1.0", false},
+		{"^0", "0.9.9", true},
+		{"^0", "1.0.0", false},
+		{"~0.2.3", "0.2.9", true},
+		{"~0.2.3", "0.3.0", false},
+	}
+
+	for _, tt := range tests {
+		r, ok := parseSemverRange(tt.spec)
+		if !ok {
+			t.Fatalf("parseSemverRange(%q) failed", tt.spec)
+		}
+		v, err := parseSemver(tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.contains(v); got != tt.want {
+			t.Errorf("%q contains %s = %v, want %v", tt.spec, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestCompareSemverPrecedence(t *testing.T) {
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"2.0.0",
+		"2.1.0",
+		"2.1.1",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			want := 0
+			switch {
+			case i < j:
+				want = -1
+			case i > j:
+				want = 1
+			}
+			got, err := compareSemver(ordered[i], ordered[j])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("compareSemver(%q, %q) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestCompareSemverIgnoresBuildMetadata(t *testing.T) {
+	if got, err := compareSemver("1.0.0+20130313144700", "1.0.0+exp.sha.5114f85"); err != nil || got != 0 {
+		t.Errorf("compareSemver with build metadata = %d, %v, want 0", got, err)
+	}
+}
+
+func TestRangeBounds(t *testing.T) {
+	tests := []struct {
+		spec    string
+		version string
+		want    bool
+	}{
+		{"^1.2.3", "1.2.3", true},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "1.2.2", false},
+		{"^1.2.3", "2.0.0", false},
+		{"^1.2.3", "2.0.0-alpha", false},
+		{"^1.2", "1.2.0", true},
+		{"^1.2", "1.1.9", false},
+		{"^1", "1.9.9", true},
+		{"^1", "2.0.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.2.2", false},
+		{"~1.2.3", "1.3.0", false},
+		{"~1.2", "1.2.9", true},
+		{"~1.2", "1.3.0", false},
+		{"~1", "1.9.0", true},
+		{"~1", "2.0.0", false},
+		{"1.x", "1.4.2", true},
+		{"1.x", "2.0.0", false},
+		{"1.2.x", "1.2.7", true},
+		{"1.2.x", "1.3.0", false},
+		{"1.2.*", "1.2.0", true},
+		{"1", "1.5.0", true},
+		{"1", "0.9.0", false},
+		{"*", "3.1.4", true},
+		{"x", "0.0.1", true},
+	}
+
+	for _, tt := range tests {
+		r, ok := parseSemverRange(tt.spec)
+		if !ok {
+			t.Fatalf("parseSemverRange(%q) failed", tt.spec)
+		}
+		v, err := parseSemver(tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.contains(v); got != tt.want {
+			t.Errorf("%q contains %s = %v, want %v", tt.spec, tt.version, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type PackageSize struct {
+	Name    string
+	Version string
+	Self    int64
+	Total   int64
+	Deps    int
+}
+
+type sizeWalker struct {
+	nodeModulesPath string
+	sizes           map[string]int64
+}
+
+func measureDependencySizes(nodeModulesPath string, names []string) ([]PackageSize, error) {
+	sw := &sizeWalker{nodeModulesPath: nodeModulesPath, sizes: make(map[string]int64)}
+
+	var result []PackageSize
+	for _, name := range names {
+		packagePath := filepath.Join(nodeModulesPath, name)
+		manifest, err := readInstalledManifest(packagePath)
+		if err != nil {
+			continue
+		}
+
+		self, err := sw.dirSize(packagePath)
+		if err != nil {
+			return nil, err
+		}
+
+		size := PackageSize{Name: name, Version: manifest.Version, Self: self, Total: self}
+		for _, depPath := range sw.hoistedClosure(packagePath) {
+			depSize, err := sw.dirSize(depPath)
+			if err != nil {
+				return nil, err
+			}
+			size.Total += depSize
+			size.Deps++
+		}
+		result = append(result, size)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+func (sw *sizeWalker) hoistedClosure(rootPath string) []string {
+	visited := map[string]bool{rootPath: true}
+	queue := []string{rootPath}
+	var hoisted []string
+
+	for len(queue) > 0 {
+		packagePath := queue[0]
+		queue = queue[1:]
+
+		manifest, err := readInstalledManifest(packagePath)
+		if err != nil {
+			continue
+		}
+
+		depNames := append(sortedKeys(manifest.Dependencies), sortedKeys(manifest.OptionalDependencies)...)
+		for _, depName := range depNames {
+			depPath := resolveInstalledDependency(sw.nodeModulesPath, packagePath, depName)
+			if depPath == "" || visited[depPath] {
+				continue
+			}
+			visited[depPath] = true
+			queue = append(queue, depPath)
+
+			if sw.isHoisted(depPath) {
+				hoisted = append(hoisted, depPath)
+			}
+		}
+	}
+
+	return hoisted
+}
+
+func (sw *sizeWalker) isHoisted(packagePath string) bool {
+	rel, err := filepath.Rel(sw.nodeModulesPath, packagePath)
+	if err != nil {
+		return false
+	}
+	return !strings.Contains(filepath.ToSlash(rel), "node_modules")
+}
+
+func (sw *sizeWalker) dirSize(path string) (int64, error) {
+	if size, ok := sw.sizes[path]; ok {
+		return size, nil
+	}
+
+	var size int64
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure %s: %v", path, err)
+	}
+
+	sw.sizes[path] = size
+	return size, nil
+}
+
+func printPackageSizes(sizes []PackageSize, top int, totalSize int64) {
+	if len(sizes) == 0 {
+		fmt.Printf(" %s No installed dependencies\n", color.HiBlackString("ℹ"))
+		return
+	}
+
+	shown := sizes
+	if top > 0 && top < len(shown) {
+		shown = shown[:top]
+	}
+
+	nameWidth := len("Package")
+	for _, size := range shown {
+		if width := len(size.Name) + len(size.Version) + 1; width > nameWidth {
+			nameWidth = width
+		}
+	}
+
+	fmt.Printf("\n %-*s  %10s  %12s  %s\n", nameWidth, "Package", "Self", "With deps", "Deps")
+	for _, size := range shown {
+		label := fmt.Sprintf("%s@%s", size.Name, size.Version)
+		fmt.Printf(" %s  %10s  %s  %s\n",
+			color.CyanString("%-*s", nameWidth, label),
+			formatBytes(size.Self),
+			color.YellowString("%12s", formatBytes(size.Total)),
+			color.HiBlackString("%d", size.Deps))
+	}
+
+	if len(shown) < len(sizes) {
+		fmt.Printf(" %s\n", color.HiBlackString("... %d more", len(sizes)-len(shown)))
+	}
+	fmt.Printf("\n %s node_modules total: %s\n\n", color.MagentaString("→"), formatBytes(totalSize))
+}
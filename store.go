@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type storeEntry struct {
+	Name      string
+	Version   string
+	Path      string
+	Present   bool
+	Problem   string
+	Integrity string
+}
+
+func inspectStoreEntry(cache *Cache, name, version string) storeEntry {
+	entry := storeEntry{
+		Name:      name,
+		Version:   version,
+		Path:      cache.tarballPath(name, version),
+		Integrity: cache.getIntegrity(name, version),
+	}
+
+	if !fileExists(entry.Path) {
+		return entry
+	}
+	entry.Present = true
+
+	err := cache.withPackageTree(name, version, func(treePath string) error {
+		entry.Problem = storeTreeProblem(treePath, name, version)
+		return nil
+	})
+	if err != nil {
+		entry.Problem = fmt.Sprintf("tarball is unreadable: %v", err)
+	}
+	if entry.Problem != "" {
+		return entry
+	}
+
+	if entry.Integrity == "" {
+		entry.Problem = "no integrity recorded"
+	}
+
+	return entry
+}
+
+func storeTreeProblem(treePath, name, version string) string {
+	data, err := os.ReadFile(filepath.Join(treePath, "package.json"))
+	if err != nil {
+		return "package.json is missing"
+	}
+
+	var pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "package.json is not valid JSON"
+	}
+	if pkg.Name != name || pkg.Version != version {
+		return fmt.Sprintf("package.json describes %s@%s", pkg.Name, pkg.Version)
+	}
+
+	if file := missingManifestFile(treePath); file != "" {
+		return fmt.Sprintf("%s is missing", file)
+	}
+	return ""
+}
+
+func verifyStoreEntry(pm *PackageManager, lockFile *LockFile, entry storeEntry) (string, error) {
+	expected := lockFile.getIntegrity(entry.Name, entry.Version)
+	source := lockFileName()
+
+	if expected == "" {
+		pkgInfo, err := pm.Resolve(entry.Name, entry.Version)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up %s@%s: %v", entry.Name, entry.Version, err)
+		}
+		expected = distIntegrity(pkgInfo.Dist)
+		source = "the registry"
+	}
+
+	if expected == "" {
+		return source, fmt.Errorf("%s has no integrity for %s@%s", source, entry.Name, entry.Version)
+	}
+	if entry.Integrity == "" {
+		return source, fmt.Errorf("no integrity recorded for the cache entry")
+	}
+	if !integrityMatches(expected, entry.Integrity) {
+		return source, fmt.Errorf("expected %s, got %s", expected, matchingDigest(expected, entry.Integrity))
+	}
+	return source, nil
+}
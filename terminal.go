@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+var interactiveOutput = detectInteractiveOutput()
+
+var progressDisabled = parseBool(os.Getenv("GPM_NO_PROGRESS"), false)
+
+func detectInteractiveOutput() bool {
+	if os.Getenv("CI") != "" {
+		return false
+	}
+
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+func showProgressOutput() bool {
+	return !progressDisabled && reporter.Human()
+}
+
+func animateOutput() bool {
+	return interactiveOutput && showProgressOutput() && !output.claimed()
+}
+
+type terminalOutput struct {
+	mu     sync.Mutex
+	owners int
+	status bool
+}
+
+var output = &terminalOutput{}
+
+func (o *terminalOutput) Status(line string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.clearLocked()
+	fmt.Print(line)
+	o.status = interactiveOutput
+}
+
+func (o *terminalOutput) Printf(format string, args ...interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.clearLocked()
+	fmt.Printf(format, args...)
+}
+
+func (o *terminalOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.status = interactiveOutput
+	return color.Output.Write(p)
+}
+
+func (o *terminalOutput) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.clearLocked()
+}
+
+func (o *terminalOutput) clearLocked() {
+	if !o.status {
+		return
+	}
+	fmt.Print("\r" + strings.Repeat(" ", 96) + "\r")
+	o.status = false
+}
+
+func (o *terminalOutput) Claim() func() {
+	o.mu.Lock()
+	o.owners++
+	o.mu.Unlock()
+
+	return func() {
+		o.mu.Lock()
+		o.owners--
+		o.mu.Unlock()
+	}
+}
+
+func (o *terminalOutput) claimed() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.owners > 0
+}
+
+func clearLine() {
+	output.Clear()
+}
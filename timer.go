@@ -8,6 +8,8 @@ import (
 	"github.com/fatih/color"
 )
 
+const timerStatusTicks = 100
+
 type Timer struct {
 	startTime   time.Time
 	stopChan    chan bool
@@ -36,6 +38,7 @@ func (t *Timer) Start() {
 	t.startTime = time.Now()
 	t.running = true
 	t.wg.Add(1)
+	emitProgress(ProgressEvent{Phase: "start"})
 
 	go t.animate()
 }
@@ -53,7 +56,8 @@ func (t *Timer) Stop() time.Duration {
 	t.wg.Wait()
 
 	elapsed := time.Since(t.startTime) - t.totalPaused
-	fmt.Print("\r                                        \r")
+	clearLine()
+	emitProgress(ProgressEvent{Phase: "done"})
 	return elapsed
 }
 
@@ -67,7 +71,7 @@ func (t *Timer) Pause() {
 
 	t.paused = true
 	t.pausedAt = time.Now()
-	fmt.Print("\r                                        \r")
+	clearLine()
 }
 
 func (t *Timer) Resume() {
@@ -90,6 +94,7 @@ func (t *Timer) animate() {
 
 	frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 	frameIndex := 0
+	ticks := 0
 
 	for {
 		select {
@@ -97,7 +102,11 @@ func (t *Timer) animate() {
 			return
 		case <-ticker.C:
 			t.mu.Lock()
-			if t.paused {
+			ticks++
+			if ticks%10 == 0 {
+				emitProgress(ProgressEvent{Phase: "working"})
+			}
+			if t.paused || !showProgressOutput() || output.claimed() {
 				t.mu.Unlock()
 				continue
 			}
@@ -105,9 +114,18 @@ func (t *Timer) animate() {
 			elapsed := time.Since(t.startTime) - t.totalPaused
 			frame := frames[frameIndex%len(frames)]
 
-			fmt.Printf("\r %s %s",
+			if !interactiveOutput {
+				if frameIndex > 0 && frameIndex%timerStatusTicks == 0 {
+					output.Printf(" Still working... %s elapsed\n", formatDuration(elapsed))
+				}
+				frameIndex++
+				t.mu.Unlock()
+				continue
+			}
+
+			output.Status(fmt.Sprintf(" %s %s",
 				color.CyanString(frame),
-				formatDuration(elapsed))
+				formatDuration(elapsed)))
 
 			frameIndex++
 			t.mu.Unlock()
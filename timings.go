@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const defaultTimingsCount = 10
+
+type packageTiming struct {
+	Resolve      time.Duration
+	Fetch        time.Duration
+	Dependencies time.Duration
+}
+
+func (t packageTiming) Total() time.Duration {
+	return t.Resolve + t.Fetch + t.Dependencies
+}
+
+type PackageTiming struct {
+	ResolveMs      int64 `json:"resolveMs"`
+	FetchMs        int64 `json:"fetchMs"`
+	DependenciesMs int64 `json:"dependenciesMs"`
+}
+
+func reportSlowestPackages(results []PackageResult, count int) {
+	var timed []PackageResult
+	for _, result := range results {
+		if result.Timing.Total() > 0 {
+			timed = append(timed, result)
+		}
+	}
+	if len(timed) == 0 {
+		return
+	}
+
+	sort.SliceStable(timed, func(i, j int) bool {
+		return timed[i].Timing.Total() > timed[j].Timing.Total()
+	})
+	if len(timed) > count {
+		timed = timed[:count]
+	}
+
+	if !reporter.Human() {
+		for _, result := range timed {
+			reporter.Report(InstallEvent{
+				Type:      "timing",
+				Package:   result.Job.Name,
+				Version:   result.InstalledVersion,
+				ElapsedMs: result.Timing.Total().Milliseconds(),
+				Timing: &PackageTiming{
+					ResolveMs:      result.Timing.Resolve.Milliseconds(),
+					FetchMs:        result.Timing.Fetch.Milliseconds(),
+					DependenciesMs: result.Timing.Dependencies.Milliseconds(),
+				},
+			})
+		}
+		return
+	}
+
+	nameWidth := 0
+	for _, result := range timed {
+		nameWidth = max(nameWidth, len(result.Job.Name)+len(result.InstalledVersion)+1)
+	}
+
+	output.Printf(" %s Slowest packages:\n", color.CyanString("⏱"))
+	for _, result := range timed {
+		output.Printf("   %s  %7s  %s\n",
+			color.CyanString("%-*s", nameWidth, fmt.Sprintf("%s@%s", result.Job.Name, result.InstalledVersion)),
+			formatDuration(result.Timing.Total()),
+			color.HiBlackString("resolve %s, fetch %s, dependencies %s",
+				formatDuration(result.Timing.Resolve),
+				formatDuration(result.Timing.Fetch),
+				formatDuration(result.Timing.Dependencies)))
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+type transferCounter struct {
+	bytes atomic.Int64
+}
+
+type transferStats struct {
+	Bytes          int64
+	BytesPerSecond float64
+	ETA            time.Duration
+}
+
+func (c *transferCounter) Write(p []byte) (int, error) {
+	c.bytes.Add(int64(len(p)))
+	return len(p), nil
+}
+
+func newTransferStats(bytes int64, elapsed time.Duration, finished, total int) transferStats {
+	stats := transferStats{Bytes: bytes}
+	if elapsed <= 0 {
+		return stats
+	}
+
+	stats.BytesPerSecond = float64(bytes) / elapsed.Seconds()
+	if finished > 0 && finished < total {
+		stats.ETA = elapsed / time.Duration(finished) * time.Duration(total-finished)
+	}
+	return stats
+}
+
+func (s transferStats) String() string {
+	var out string
+	if s.Bytes > 0 {
+		out += fmt.Sprintf("  %s/s", formatBytes(int64(s.BytesPerSecond)))
+	}
+	if s.ETA > 0 {
+		eta := s.ETA.Round(time.Second)
+		if eta < time.Second {
+			eta = time.Second
+		}
+		out += fmt.Sprintf("  ETA %s", eta)
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+type TreeProblem struct {
+	Parent    string
+	DepName   string
+	Range     string
+	Installed string
+}
+
+func checkInstalledTree(nodeModulesPath string) ([]TreeProblem, error) {
+	packages, err := listInstalledPackages(nodeModulesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []TreeProblem
+	for _, name := range packages {
+		packagePath := filepath.Join(nodeModulesPath, name)
+		manifest, err := readInstalledManifest(packagePath)
+		if err != nil {
+			continue
+		}
+
+		depNames := make([]string, 0, len(manifest.Dependencies))
+		for depName := range manifest.Dependencies {
+			depNames = append(depNames, depName)
+		}
+		sort.Strings(depNames)
+
+		for _, depName := range depNames {
+			depRange := manifest.Dependencies[depName]
+			problem := TreeProblem{
+				Parent:  fmt.Sprintf("%s@%s", name, manifest.Version),
+				DepName: depName,
+				Range:   depRange,
+			}
+
+			depPath := resolveInstalledDependency(nodeModulesPath, packagePath, depName)
+			if depPath == "" {
+				problems = append(problems, problem)
+				continue
+			}
+
+			depManifest, err := readInstalledManifest(depPath)
+			if err != nil {
+				problems = append(problems, problem)
+				continue
+			}
+
+			if !satisfiesRange(depManifest.Version, depRange) {
+				problem.Installed = depManifest.Version
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+func printTreeProblems(problems []TreeProblem) {
+	if len(problems) == 0 {
+		fmt.Printf(" %s Dependency tree is complete\n", color.HiGreenString("✓"))
+		return
+	}
+
+	fmt.Printf(" %s %d unmet dependencies in node_modules\n", color.YellowString("⚠"), len(problems))
+	for _, problem := range problems {
+		if problem.Installed == "" {
+			fmt.Printf("   %s requires %s@%s %s\n",
+				color.CyanString(problem.Parent),
+				color.CyanString(problem.DepName),
+				color.HiBlackString(problem.Range),
+				color.RedString("(missing)"))
+		} else {
+			fmt.Printf("   %s requires %s@%s %s\n",
+				color.CyanString(problem.Parent),
+				color.CyanString(problem.DepName),
+				color.HiBlackString(problem.Range),
+				color.YellowString("(found %s)", problem.Installed))
+		}
+	}
+}
@@ -20,7 +20,7 @@ func NewTUI() *TUI {
 	}
 }
 
-func (t *TUI) SelectPackagesToUpgrade(upgrades []UpgradeInfo) ([]UpgradeInfo, error) {
+func (t *TUI) SelectPackagesToUpgrade(upgrades []UpgradeInfo, includePinned bool) ([]UpgradeInfo, error) {
 	if len(upgrades) == 0 {
 		return upgrades, nil
 	}
@@ -55,7 +55,7 @@ func (t *TUI) SelectPackagesToUpgrade(upgrades []UpgradeInfo) ([]UpgradeInfo, er
 				devTag = color.HiBlackString(" (dev)")
 			}
 
-			fmt.Printf("   %s %s %s %s %s%s\n", indexStr, name, current, arrow, latest, devTag)
+			fmt.Printf("   %s %s %s %s %s%s%s\n", indexStr, name, current, arrow, latest, devTag, pinnedTag(upgrade))
 			upgradeablePackages = append(upgradeablePackages, upgrade)
 			index++
 		}
@@ -64,6 +64,9 @@ func (t *TUI) SelectPackagesToUpgrade(upgrades []UpgradeInfo) ([]UpgradeInfo, er
 	fmt.Println()
 	fmt.Printf(" %s Select packages to upgrade:\n", color.CyanString("?"))
 	fmt.Printf("   %s\n", color.HiBlackString("Enter numbers (e.g., 1,3,5) or 'a' for all, 'n' for none:"))
+	if !includePinned && hasPinned(upgradeablePackages) {
+		fmt.Printf("   %s\n", color.HiBlackString("'a' skips pinned packages; pick them by number to upgrade them"))
+	}
 	fmt.Print(" > ")
 
 	input, err := t.reader.ReadString('\n')
@@ -79,8 +82,14 @@ func (t *TUI) SelectPackagesToUpgrade(upgrades []UpgradeInfo) ([]UpgradeInfo, er
 	}
 
 	if strings.ToLower(input) == "a" || strings.ToLower(input) == "all" {
-		fmt.Printf(" %s Selected all %d packages for upgrade\n", color.GreenString("✓"), len(upgradeablePackages))
-		return upgradeablePackages, nil
+		selected := upgradeablePackages
+		if !includePinned {
+			var pinned []UpgradeInfo
+			selected, pinned = excludePinned(upgradeablePackages)
+			printSkippedPinned(pinned)
+		}
+		fmt.Printf(" %s Selected all %d packages for upgrade\n", color.GreenString("✓"), len(selected))
+		return selected, nil
 	}
 
 	selected, err := t.parseSelection(input, len(upgradeablePackages))
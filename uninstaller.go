@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,10 +8,31 @@ import (
 	"github.com/fatih/color"
 )
 
-func uninstallPackage(packageName string, lockFile *LockFile) error {
-	nodeModulesPath := "./node_modules"
+const (
+	groupDependencies    = "dependencies"
+	groupDevDependencies = "devDependencies"
+)
+
+func uninstallPackage(packageName, group string, lockFile *LockFile) error {
+	nodeModulesPath := nodeModulesDir()
 	packagePath := filepath.Join(nodeModulesPath, packageName)
 
+	if group != "" {
+		removed, remaining, err := removeFromPackageJSON(packageName, group)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			fmt.Printf(" %s %s is not in %s\n", color.YellowString("⚠"), color.CyanString(packageName), group)
+			return nil
+		}
+		if remaining != "" {
+			lockFile.setDevDependency(packageName, remaining == groupDevDependencies)
+			fmt.Printf(" %s %s removed from %s %s\n", color.HiGreenString("✓"), color.CyanString(packageName), group, color.HiBlackString("(still in %s)", remaining))
+			return nil
+		}
+	}
+
 	if !fileExists(packagePath) {
 		fmt.Printf(" %s %s is not installed\n", color.YellowString("⚠"), color.CyanString(packageName))
 		return nil
@@ -30,8 +50,10 @@ func uninstallPackage(packageName string, lockFile *LockFile) error {
 		return fmt.Errorf("failed to remove package directory: %v", err)
 	}
 
-	if err := removeFromPackageJSON(packageName); err != nil {
-		fmt.Printf(" %s Failed to update package.json: %v\n", color.YellowString("⚠"), err)
+	if group == "" {
+		if _, _, err := removeFromPackageJSON(packageName, ""); err != nil {
+			fmt.Printf(" %s Failed to update package.json: %v\n", color.YellowString("⚠"), err)
+		}
 	}
 
 	lockFile.removePackage(packageName)
@@ -40,44 +62,51 @@ func uninstallPackage(packageName string, lockFile *LockFile) error {
 	return nil
 }
 
-func removeFromPackageJSON(packageName string) error {
+func removeFromPackageJSON(packageName, group string) (bool, string, error) {
 	data, err := os.ReadFile("package.json")
 	if err != nil {
-		return fmt.Errorf("failed to read package.json: %v", err)
+		return false, "", fmt.Errorf("failed to read package.json: %v", err)
 	}
 
 	var pkg PackageJSON
-	if err := json.Unmarshal(data, &pkg); err != nil {
-		return fmt.Errorf("failed to parse package.json: %v", err)
+	if err := parseManifest(data, &pkg); err != nil {
+		return false, "", fmt.Errorf("failed to parse package.json: %v", err)
 	}
 
-	removed := false
-	if pkg.Dependencies != nil {
-		if _, exists := pkg.Dependencies[packageName]; exists {
-			delete(pkg.Dependencies, packageName)
-			removed = true
+	var sections []string
+	remaining := ""
+	if _, exists := pkg.Dependencies[packageName]; exists {
+		if group == "" || group == groupDependencies {
+			sections = append(sections, groupDependencies)
+		} else {
+			remaining = groupDependencies
 		}
 	}
 
-	if pkg.DevDependencies != nil {
-		if _, exists := pkg.DevDependencies[packageName]; exists {
-			delete(pkg.DevDependencies, packageName)
-			removed = true
+	if _, exists := pkg.DevDependencies[packageName]; exists {
+		if group == "" || group == groupDevDependencies {
+			sections = append(sections, groupDevDependencies)
+		} else {
+			remaining = groupDevDependencies
 		}
 	}
 
-	if !removed {
-		return nil
+	if len(sections) == 0 {
+		return false, remaining, nil
 	}
 
-	updatedData, err := json.MarshalIndent(pkg, "", "  ")
+	err = editManifestFile("package.json", func(data []byte) ([]byte, error) {
+		for _, section := range sections {
+			var err error
+			if data, _, err = removeManifestEntry(data, section, packageName); err != nil {
+				return nil, err
+			}
+		}
+		return data, nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal package.json: %v", err)
+		return false, "", err
 	}
 
-	if err := os.WriteFile("package.json", updatedData, 0644); err != nil {
-		return fmt.Errorf("failed to write package.json: %v", err)
-	}
-
-	return nil
+	return true, remaining, nil
 }
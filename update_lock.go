@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type LockChange struct {
+	Name       string
+	OldVersion string
+	NewVersion string
+}
+
+type lockUpdater struct {
+	pm        *PackageManager
+	current   *LockFile
+	updated   *LockFile
+	responses map[string]*RegistryResponse
+}
+
+func updateLockFile(pm *PackageManager, current *LockFile) (*LockFile, []LockChange, error) {
+	pkg, err := loadPackageJSON("package.json")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lu := &lockUpdater{
+		pm:        pm,
+		current:   current,
+		updated:   newLockFile(),
+		responses: make(map[string]*RegistryResponse),
+	}
+
+	for name, specifier := range current.Specifiers {
+		lu.updated.Specifiers[name] = specifier
+	}
+	for name, specifier := range current.DevPackages {
+		lu.updated.DevPackages[name] = specifier
+	}
+
+	for _, name := range sortedKeys(pkg.Dependencies) {
+		if err := lu.resolve(name, pkg.Dependencies[name], false, true); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, name := range sortedKeys(pkg.DevDependencies) {
+		if err := lu.resolve(name, pkg.DevDependencies[name], true, true); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return lu.updated, diffLockFiles(current, lu.updated), nil
+}
+
+func (lu *lockUpdater) resolve(name, versionRange string, isDev, direct bool) error {
+	registryName := name
+	if realName, realRange, ok := parseAliasVersion(versionRange); ok {
+		registryName = realName
+		versionRange = realRange
+	}
+
+	registryResp, err := lu.registryResponse(registryName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %v", name, err)
+	}
+
+	version := lu.newestSatisfying(versionRange, registryResp)
+	if version == "" {
+		version = lu.current.getPackageVersion(name)
+		if _, ok := registryResp.Versions[version]; !ok {
+			return fmt.Errorf("no version of %s satisfies %s", registryName, versionRange)
+		}
+		reportWarning("Could not re-resolve %s@%s, keeping %s", name, versionRange, version)
+	}
+
+	packageKey := fmt.Sprintf("%s@%s", name, version)
+	if existing, ok := lu.updated.Packages[packageKey]; ok {
+		if existing.DevDep && !isDev {
+			existing.DevDep = false
+			lu.updated.Packages[packageKey] = existing
+		}
+		return nil
+	}
+
+	pkgInfo := registryResp.Versions[version]
+	integrity := lu.current.getIntegrity(name, version)
+	if integrity == "" {
+		integrity = distIntegrity(pkgInfo.Dist)
+	}
+
+	lu.updated.Packages[packageKey] = LockPackage{
+		Name:         name,
+		Version:      version,
+		Resolved:     pkgInfo.Dist.Tarball,
+		Integrity:    integrity,
+		Dependencies: pkgInfo.Dependencies,
+		DevDep:       isDev,
+		Direct:       direct,
+	}
+
+	for _, depName := range sortedKeys(pkgInfo.Dependencies) {
+		if err := lu.resolve(depName, pkgInfo.Dependencies[depName], isDev, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (lu *lockUpdater) registryResponse(name string) (*RegistryResponse, error) {
+	if registryResp, ok := lu.responses[name]; ok {
+		return registryResp, nil
+	}
+
+	registryResp, err := lu.pm.fetchRegistryResponse(name)
+	if err != nil {
+		return nil, err
+	}
+	lu.responses[name] = registryResp
+	return registryResp, nil
+}
+
+func (lu *lockUpdater) newestSatisfying(versionRange string, registryResp *RegistryResponse) string {
+	switch strings.TrimSpace(versionRange) {
+	case "", "*", "latest":
+		return registryResp.DistTags["latest"]
+	}
+	return resolveVersionRange(versionRange, registryResp.Versions)
+}
+
+func diffLockFiles(old, updated *LockFile) []LockChange {
+	oldVersions := lockedVersionsByName(old)
+	newVersions := lockedVersionsByName(updated)
+
+	names := make(map[string]bool)
+	for name := range oldVersions {
+		names[name] = true
+	}
+	for name := range newVersions {
+		names[name] = true
+	}
+
+	var changes []LockChange
+	for name := range names {
+		oldVersion := strings.Join(oldVersions[name], ", ")
+		newVersion := strings.Join(newVersions[name], ", ")
+		if oldVersion != newVersion {
+			changes = append(changes, LockChange{Name: name, OldVersion: oldVersion, NewVersion: newVersion})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+func lockedVersionsByName(lockFile *LockFile) map[string][]string {
+	versions := make(map[string][]string)
+	for _, lockPkg := range lockFile.Packages {
+		versions[lockPkg.Name] = append(versions[lockPkg.Name], lockPkg.Version)
+	}
+	for name := range versions {
+		sort.Slice(versions[name], func(i, j int) bool {
+			return compareVersions(versions[name][i], versions[name][j]) < 0
+		})
+	}
+	return versions
+}
+
+func printLockChanges(changes []LockChange) {
+	if len(changes) == 0 {
+		fmt.Printf(" %s All locked versions are current\n", color.GreenString("✓"))
+		return
+	}
+
+	fmt.Printf("\n %s %d locked package(s) changed:\n\n", color.YellowString("⬆"), len(changes))
+	for _, change := range changes {
+		oldVersion := change.OldVersion
+		if oldVersion == "" {
+			oldVersion = "(new)"
+		}
+		newVersion := change.NewVersion
+		if newVersion == "" {
+			newVersion = "(removed)"
+		}
+		fmt.Printf("   %s %s %s %s\n", color.CyanString(change.Name), color.RedString(oldVersion), color.BlueString("→"), color.GreenString(newVersion))
+	}
+	fmt.Println()
+}
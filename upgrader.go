@@ -20,8 +20,11 @@ type UpgradeInfo struct {
 	Name           string
 	CurrentVersion string
 	LatestVersion  string
+	WantedVersion  string
 	NeedsUpgrade   bool
 	IsDev          bool
+	DeclaredRange  string
+	Pinned         bool
 }
 
 func NewUpgradeManager(pm *PackageManager, lockFile *LockFile) *UpgradeManager {
@@ -61,13 +64,37 @@ func (um *UpgradeManager) checkSinglePackage(packageName string) (UpgradeInfo, e
 	info.LatestVersion = latestVersion
 
 	info.NeedsUpgrade = um.needsUpgrade(currentVersion, latestVersion)
-	info.IsDev = um.isDevDependency(packageName)
+	info.DeclaredRange, info.IsDev = um.declaredDependency(packageName)
+	info.Pinned = isPinnedRange(info.DeclaredRange)
+	info.WantedVersion = um.getWantedVersion(packageName, info.DeclaredRange)
 
 	return info, nil
 }
 
+func (um *UpgradeManager) getWantedVersion(packageName, declaredRange string) string {
+	if declaredRange == "" {
+		return ""
+	}
+
+	index, err := um.pm.fetchPackumentIndex(packageName, func(string, map[string]string) bool { return false })
+	if err != nil {
+		return ""
+	}
+
+	var wanted string
+	for _, version := range index.Names {
+		if strings.Contains(version, "-") && !strings.Contains(declaredRange, "-") {
+			continue
+		}
+		if satisfiesRange(version, declaredRange) && (wanted == "" || compareVersions(version, wanted) > 0) {
+			wanted = version
+		}
+	}
+	return wanted
+}
+
 func (um *UpgradeManager) getCurrentVersion(packageName string) string {
-	packagePath := filepath.Join("node_modules", packageName, "package.json")
+	packagePath := filepath.Join(nodeModulesDir(), packageName, "package.json")
 	if !fileExists(packagePath) {
 		return ""
 	}
@@ -100,25 +127,67 @@ func (um *UpgradeManager) needsUpgrade(current, latest string) bool {
 	return compareVersions(current, latest) < 0
 }
 
-func (um *UpgradeManager) isDevDependency(packageName string) bool {
+func (um *UpgradeManager) declaredDependency(packageName string) (string, bool) {
 	data, err := os.ReadFile("package.json")
 	if err != nil {
-		return false
+		return "", false
 	}
 
 	var pkg PackageJSON
 	if err := json.Unmarshal(data, &pkg); err != nil {
-		return false
+		return "", false
+	}
+
+	if spec, exists := pkg.DevDependencies[packageName]; exists {
+		return spec, true
+	}
+	return pkg.Dependencies[packageName], false
+}
+
+func isPinnedRange(spec string) bool {
+	if _, version, ok := parseAliasVersion(spec); ok {
+		spec = version
 	}
+	_, err := validateVersion(spec)
+	return err == nil
+}
 
-	if pkg.DevDependencies != nil {
-		_, exists := pkg.DevDependencies[packageName]
-		return exists
+func excludePinned(upgrades []UpgradeInfo) ([]UpgradeInfo, []UpgradeInfo) {
+	var selected, pinned []UpgradeInfo
+	for _, upgrade := range upgrades {
+		if upgrade.Pinned {
+			pinned = append(pinned, upgrade)
+		} else {
+			selected = append(selected, upgrade)
+		}
 	}
+	return selected, pinned
+}
 
+func hasPinned(upgrades []UpgradeInfo) bool {
+	for _, upgrade := range upgrades {
+		if upgrade.Pinned {
+			return true
+		}
+	}
 	return false
 }
 
+func printSkippedPinned(pinned []UpgradeInfo) {
+	for _, upgrade := range pinned {
+		if upgrade.NeedsUpgrade {
+			fmt.Printf(" %s Skipped %s %s (pinned, use --latest or name it to upgrade)\n", color.HiBlackString("ℹ"), color.CyanString(upgrade.Name), color.HiBlackString(upgrade.DeclaredRange))
+		}
+	}
+}
+
+func pinnedTag(upgrade UpgradeInfo) string {
+	if upgrade.Pinned {
+		return color.MagentaString(" (pinned)")
+	}
+	return ""
+}
+
 func (um *UpgradeManager) ShowUpgradePreview(upgrades []UpgradeInfo) {
 	if len(upgrades) == 0 {
 		fmt.Printf(" %s No packages to upgrade\n", color.GreenString("✓"))
@@ -151,13 +220,20 @@ func (um *UpgradeManager) ShowUpgradePreview(upgrades []UpgradeInfo) {
 				devTag = color.HiBlackString(" (dev)")
 			}
 
-			fmt.Printf("   %s %s %s %s%s\n", name, current, arrow, latest, devTag)
+			fmt.Printf("   %s %s %s %s%s%s\n", name, current, arrow, latest, devTag, pinnedTag(upgrade))
 		}
 	}
 	fmt.Println()
 }
 
 func compareVersions(v1, v2 string) int {
+	if cmp, err := compareSemver(v1, v2); err == nil {
+		return cmp
+	}
+	return compareLooseVersions(v1, v2)
+}
+
+func compareLooseVersions(v1, v2 string) int {
 	parts1 := strings.Split(v1, ".")
 	parts2 := strings.Split(v2, ".")
 
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type VerifyProblem struct {
+	Package   string
+	Locked    string
+	Installed string
+	Reason    string
+}
+
+func verifyInstall(nodeModulesPath string, lockFile *LockFile, cache *Cache, checkIntegrity bool) ([]VerifyProblem, int, error) {
+	locked := make(map[string][]string)
+	for _, lockPkg := range lockFile.Packages {
+		if !lockPkg.Skipped {
+			locked[lockPkg.Name] = append(locked[lockPkg.Name], lockPkg.Version)
+		}
+	}
+
+	names := make([]string, 0, len(locked))
+	for name := range locked {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []VerifyProblem
+	for _, name := range names {
+		versions := locked[name]
+		sort.Slice(versions, func(i, j int) bool {
+			return compareVersions(versions[i], versions[j]) < 0
+		})
+		problem := VerifyProblem{Package: name, Locked: strings.Join(versions, ", ")}
+
+		packagePath := filepath.Join(nodeModulesPath, name)
+		manifest, err := readInstalledManifest(packagePath)
+		if err != nil {
+			problem.Reason = "missing"
+			problems = append(problems, problem)
+			continue
+		}
+		problem.Installed = manifest.Version
+
+		matched := false
+		for _, version := range versions {
+			if version == manifest.Version {
+				matched = true
+			}
+		}
+		if !matched {
+			problem.Reason = "version mismatch"
+			problems = append(problems, problem)
+			continue
+		}
+
+		if checkIntegrity {
+			if reason := verifyInstalledIntegrity(packagePath, name, manifest.Version, lockFile, cache); reason != "" {
+				problem.Reason = reason
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	installed, err := listInstalledPackages(nodeModulesPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, name := range installed {
+		if _, ok := locked[name]; ok || lockFile.isSkippedOptional(name) {
+			continue
+		}
+
+		problem := VerifyProblem{Package: name, Reason: "extraneous"}
+		if manifest, err := readInstalledManifest(filepath.Join(nodeModulesPath, name)); err == nil {
+			problem.Installed = manifest.Version
+		}
+		problems = append(problems, problem)
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Package < problems[j].Package
+	})
+	return problems, len(locked), nil
+}
+
+func verifyInstalledIntegrity(packagePath, name, version string, lockFile *LockFile, cache *Cache) string {
+	expected := lockFile.getIntegrity(name, version)
+	if expected == "" {
+		return "no integrity recorded"
+	}
+
+	if !cache.hasPackage(name, version) {
+		return "cannot check integrity (not in cache)"
+	}
+	if actual := cache.getIntegrity(name, version); !integrityMatches(expected, actual) {
+		return fmt.Sprintf("integrity mismatch (cache has %s)", actual)
+	}
+	var file string
+	err := cache.withPackageTree(name, version, func(cachePath string) error {
+		file = missingCachedFile(packagePath, cachePath)
+		return nil
+	})
+	if err != nil {
+		return fmt.Sprintf("cannot check integrity (%v)", err)
+	}
+	if file != "" {
+		return fmt.Sprintf("%s is missing or modified", file)
+	}
+	return ""
+}
+
+func printVerifyProblems(problems []VerifyProblem, lockedCount int) {
+	if len(problems) == 0 {
+		fmt.Printf(" %s node_modules matches %s (%d packages)\n", color.HiGreenString("✓"), lockFileName(), lockedCount)
+		return
+	}
+
+	fmt.Printf(" %s node_modules does not match %s (%d problems)\n", color.RedString("✗"), lockFileName(), len(problems))
+	for _, problem := range problems {
+		switch problem.Reason {
+		case "missing":
+			fmt.Printf("   %s %s\n", color.CyanString("%s@%s", problem.Package, problem.Locked), color.RedString("(missing)"))
+		case "extraneous":
+			fmt.Printf("   %s %s\n", color.CyanString("%s@%s", problem.Package, problem.Installed), color.YellowString("(extraneous)"))
+		case "version mismatch":
+			fmt.Printf("   %s %s\n", color.CyanString("%s@%s", problem.Package, problem.Locked), color.YellowString("(found %s)", problem.Installed))
+		default:
+			fmt.Printf("   %s %s\n", color.CyanString("%s@%s", problem.Package, problem.Installed), color.RedString("(%s)", problem.Reason))
+		}
+	}
+}
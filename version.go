@@ -0,0 +1,29 @@
+package main
+
+import (
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+var gpmVersion = "dev"
+
+var (
+	resolveVersionOnce   sync.Once
+	pseudoVersionPattern = regexp.MustCompile(`\d{14}-[0-9a-f]{12}`)
+)
+
+func currentGpmVersion() string {
+	resolveVersionOnce.Do(func() {
+		if gpmVersion != "dev" {
+			return
+		}
+		info, ok := debug.ReadBuildInfo()
+		if !ok || info.Main.Version == "" || info.Main.Version == "(devel)" || pseudoVersionPattern.MatchString(info.Main.Version) {
+			return
+		}
+		gpmVersion = info.Main.Version
+	})
+	return strings.TrimPrefix(gpmVersion, "v")
+}